@@ -3,7 +3,6 @@ module github.com/phillip-england/gtml
 go 1.23.3
 
 require (
-	github.com/PuerkitoBio/goquery v1.10.3 // indirect
-	github.com/andybalholm/cascadia v1.3.3 // indirect
-	golang.org/x/net v0.39.0 // indirect
+	github.com/andybalholm/cascadia v1.3.3
+	golang.org/x/net v0.39.0
 )
@@ -1,5 +1,11 @@
 package lexer
 
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
 // Lexer represents a simple rune-based lexer for walking over text input.
 type Lexer struct {
 	Pos         int          // Current position in the source.
@@ -13,6 +19,29 @@ type Lexer struct {
 	Spent []rune
 	Line int
 	Column int
+	Offset int // Byte offset of Pos in the UTF-8 encoded source.
+
+	lineStarts  []int  // Rune offsets of every line start seen so far.
+	lineOffsets []int  // Byte offsets of the same line starts.
+	scanned     int    // Runes already checked for line breaks.
+	scannedOff  int    // Byte offset of scanned.
+	linePos     int    // Position that Line, Column and Offset describe.
+	mark        Cursor // Cursor at MarkedPos, recorded by Mark.
+	checkpoints []Checkpoint
+}
+
+// Checkpoint is a saved location on the lexer's checkpoint stack.
+type Checkpoint struct {
+	Cursor
+	Depth int // Stack depth of the checkpoint, starting at 1.
+}
+
+// Cursor is a snapshot of a location in the source.
+type Cursor struct {
+	Pos    int // Rune offset.
+	Offset int // Byte offset.
+	Line   int // 1-based line.
+	Column int // 1-based column, counted in runes.
 }
 
 // NewLexer creates and initializes a new Lexer from the given rune slice.
@@ -23,6 +52,11 @@ func NewLexer(runes []rune) *Lexer {
 		MarkedPos:   0,
 		CharCounter: make(map[rune]int),
 		Spent: []rune{},
+		Line:        1,
+		Column:      1,
+		lineStarts:  []int{0},
+		lineOffsets: []int{0},
+		mark:        Cursor{Line: 1, Column: 1},
 	}
 	if len(runes) == 0 {
 		l.Current = 0
@@ -74,6 +108,7 @@ func (l *Lexer) StepBack() {
 // Mark saves the current position.
 func (l *Lexer) Mark() {
 	l.MarkedPos = l.Pos
+	l.mark = l.Cursor()
 }
 
 // JumpToMark repositions the lexer back to the last marked position.
@@ -82,21 +117,96 @@ func (l *Lexer) JumpToMark() {
 		l.Pos = l.MarkedPos
 		l.Current = l.Source[l.Pos]
 		l.Terminated = false
+		if l.mark.Pos == l.MarkedPos {
+			l.Line = l.mark.Line
+			l.Offset = l.mark.Offset
+			l.linePos = l.MarkedPos
+		}
 		l.updateSpent()
 		l.updateLineAndColumn()
 	}
 }
 
+// Checkpoint pushes the current location onto the checkpoint stack and
+// returns it. Unlike Mark, checkpoints nest: an inner sub-scan can take
+// its own checkpoint without losing the outer one.
+func (l *Lexer) Checkpoint() Checkpoint {
+	cp := Checkpoint{Cursor: l.Cursor(), Depth: len(l.checkpoints) + 1}
+	l.checkpoints = append(l.checkpoints, cp)
+	return cp
+}
+
+// Restore pops the innermost checkpoint and moves the lexer back to it.
+// It returns false if there is no checkpoint to restore.
+func (l *Lexer) Restore() bool {
+	cp, ok := l.popCheckpoint()
+	if !ok {
+		return false
+	}
+	if cp.Pos < 0 || cp.Pos >= len(l.Source) {
+		return true
+	}
+	l.Pos = cp.Pos
+	l.Current = l.Source[l.Pos]
+	l.Terminated = false
+	l.Line = cp.Line
+	l.Offset = cp.Offset
+	l.linePos = cp.Pos
+	l.updateSpent()
+	l.updateLineAndColumn()
+	return true
+}
+
+// Commit pops the innermost checkpoint and keeps the current position.
+// It returns false if there is no checkpoint to commit.
+func (l *Lexer) Commit() bool {
+	_, ok := l.popCheckpoint()
+	return ok
+}
+
+// Checkpoints returns how many checkpoints are on the stack.
+func (l *Lexer) Checkpoints() int {
+	return len(l.checkpoints)
+}
+
+func (l *Lexer) popCheckpoint() (Checkpoint, bool) {
+	if len(l.checkpoints) == 0 {
+		return Checkpoint{}, false
+	}
+	cp := l.checkpoints[len(l.checkpoints)-1]
+	l.checkpoints = l.checkpoints[:len(l.checkpoints)-1]
+	return cp, true
+}
+
+// CollectFromCheckpoint returns all runes from the checkpoint up to the
+// current Pos. The checkpoint does not have to be on top of the stack.
+func (l *Lexer) CollectFromCheckpoint(cp Checkpoint) []rune {
+	return l.collectBetween(cp.Pos, l.Pos)
+}
+
+// FlushFromCheckpoint collects runes from the checkpoint and clears the buffer.
+func (l *Lexer) FlushFromCheckpoint(cp Checkpoint) []rune {
+	collected := l.CollectFromCheckpoint(cp)
+	l.Buffer = []rune{}
+	return collected
+}
+
 
 // CollectFromMark returns all runes from MarkedPos up to current Pos.
 func (l *Lexer) CollectFromMark() []rune {
-	if l.MarkedPos < 0  || l.Pos >= len(l.Source) {
+	return l.collectBetween(l.MarkedPos, l.Pos)
+}
+
+// collectBetween returns the runes from one position through another,
+// inclusive, in source order.
+func (l *Lexer) collectBetween(from, to int) []rune {
+	if from < 0 || to < 0 || from >= len(l.Source) || to >= len(l.Source) {
 		return nil
 	}
-	if l.Pos+1 > l.MarkedPos {
-		return l.Source[l.MarkedPos : l.Pos+1]
+	if to+1 > from {
+		return l.Source[from : to+1]
 	} else {
-		return l.Source[l.Pos : l.MarkedPos+1]
+		return l.Source[to : from+1]
 	}
 }
 
@@ -137,6 +247,35 @@ func (l *Lexer) WalkUntil(target rune) bool {
 	return false
 }
 
+// WalkUntilSequence steps forward until the runes ending at the current
+// position spell seq, leaving the lexer on the last rune of seq.
+func (l *Lexer) WalkUntilSequence(seq string) bool {
+	target := []rune(seq)
+	for !l.Terminated {
+		l.Step()
+		if l.Terminated {
+			break
+		}
+		if l.Pos+1 >= len(target) && string(l.Source[l.Pos+1-len(target):l.Pos+1]) == seq {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPrefix reports whether the source starting at the current rune
+// begins with s.
+func (l *Lexer) HasPrefix(s string) bool {
+	n := len([]rune(s))
+	return l.Pos+n <= len(l.Source) && string(l.Source[l.Pos:l.Pos+n]) == s
+}
+
+// HasPrefixFold is like HasPrefix but ignores case.
+func (l *Lexer) HasPrefixFold(s string) bool {
+	n := len([]rune(s))
+	return l.Pos+n <= len(l.Source) && strings.EqualFold(string(l.Source[l.Pos:l.Pos+n]), s)
+}
+
 // WalkBackUntil steps back until target rune is found.
 func (l *Lexer) WalkBackUntil(target rune) bool {
 	for l.Pos > 0 {
@@ -229,17 +368,108 @@ func (l *Lexer) updateSpent() {
 	}
 }
 
+// updateLineAndColumn moves Line, Column and Offset to Pos. Single
+// steps in either direction and jumps to a mark cost O(1); any other
+// jump (such as Pos being assigned directly) falls back to a binary
+// search over the line starts seen so far.
 func (l *Lexer) updateLineAndColumn() {
-	l.Line = 1
-	l.Column = 1
-	for i := 0; i < l.Pos; i++ {
-		if l.Source[i] == '\n' {
+	if len(l.Source) == 0 || l.Pos < 0 || l.Pos >= len(l.Source) {
+		return
+	}
+	switch l.Pos {
+	case l.linePos:
+	case l.linePos + 1:
+		prev := l.Source[l.Pos-1]
+		l.Offset += runeLen(prev)
+		if prev == '\n' {
 			l.Line++
-			l.Column = 1
-		} else {
-			l.Column++
 		}
+	case l.linePos - 1:
+		l.Offset -= runeLen(l.Current)
+		if l.Current == '\n' {
+			l.Line--
+		}
+	default:
+		c := l.CursorAt(l.Pos)
+		l.Line = c.Line
+		l.Offset = c.Offset
+	}
+	l.scanLines(l.Pos)
+	l.Column = l.Pos - l.lineStarts[l.Line-1] + 1
+	l.linePos = l.Pos
+}
+
+// CursorAt resolves an arbitrary position without moving the lexer.
+func (l *Lexer) CursorAt(pos int) Cursor {
+	l.scanLines(pos)
+	line := sort.SearchInts(l.lineStarts, pos+1)
+	c := Cursor{
+		Pos:    pos,
+		Offset: l.lineOffsets[line-1],
+		Line:   line,
+		Column: pos - l.lineStarts[line-1] + 1,
+	}
+	for _, r := range l.Source[l.lineStarts[line-1]:pos] {
+		c.Offset += runeLen(r)
+	}
+	return c
+}
+
+// scanLines records the start of every line beginning at or before pos.
+// Each rune is only ever checked once.
+func (l *Lexer) scanLines(pos int) {
+	for ; l.scanned < pos && l.scanned < len(l.Source); l.scanned++ {
+		r := l.Source[l.scanned]
+		l.scannedOff += runeLen(r)
+		if r == '\n' {
+			l.lineStarts = append(l.lineStarts, l.scanned+1)
+			l.lineOffsets = append(l.lineOffsets, l.scannedOff)
+		}
+	}
+}
+
+// runeLen is the number of bytes r takes up once the source is encoded
+// back to UTF-8. Invalid runes are written as utf8.RuneError.
+func runeLen(r rune) int {
+	if n := utf8.RuneLen(r); n > 0 {
+		return n
+	}
+	return utf8.RuneLen(utf8.RuneError)
+}
+
+// Cursor returns the location of the current rune.
+func (l *Lexer) Cursor() Cursor {
+	l.updateLineAndColumn()
+	return Cursor{Pos: l.Pos, Offset: l.Offset, Line: l.Line, Column: l.Column}
+}
+
+// CursorAfter returns the location just past the current rune, which is
+// where a span ending on the current rune stops.
+func (l *Lexer) CursorAfter() Cursor {
+	c := l.Cursor()
+	if len(l.Source) == 0 {
+		return c
 	}
+	c.Pos++
+	c.Offset += runeLen(l.Current)
+	if l.Current == '\n' {
+		c.Line++
+		c.Column = 1
+	} else {
+		c.Column++
+	}
+	return c
+}
+
+// MarkedCursor returns the location of MarkedPos.
+func (l *Lexer) MarkedCursor() Cursor {
+	if l.mark.Pos == l.MarkedPos {
+		return l.mark
+	}
+	if l.MarkedPos < 0 || l.MarkedPos >= len(l.Source) {
+		return Cursor{Line: 1, Column: 1}
+	}
+	return l.CursorAt(l.MarkedPos)
 }
 
 // SpentString returns the spent runes as a string.
@@ -254,6 +484,9 @@ func (l *Lexer) WalkBackToStart() {
 	if !l.Terminated {
 		l.Current = l.Source[0]
 	}
+	l.Line = 1
+	l.Offset = 0
+	l.linePos = 0
 	l.updateSpent()
 	l.updateLineAndColumn()
 }
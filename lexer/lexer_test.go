@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -141,4 +143,103 @@ func TestLexerNavigation(t *testing.T) {
 	if len(l.FlushFromMark()) != len(input) {
 		t.Errorf(`FlushFromMark should be equal to the len of the input but its not input len is %d and FlushFromMark len is %d`, len(input), len(l.FlushFromMark()))
 	}
-}
\ No newline at end of file
+}
+func TestLexerLineAndColumn(t *testing.T) {
+	input := []rune("<ul>\n  <li>one</li>\n\n  <li>two</li>\n</ul>")
+	// naively compute where every position should land
+	lines := make([]int, len(input))
+	cols := make([]int, len(input))
+	line, col := 1, 1
+	for i, r := range input {
+		lines[i], cols[i] = line, col
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	l := NewLexer(input)
+	expect := func(step string) {
+		if l.Line != lines[l.Pos] || l.Column != cols[l.Pos] {
+			t.Errorf(`after %s at position %d expected line %d column %d but got line %d column %d`, step, l.Pos, lines[l.Pos], cols[l.Pos], l.Line, l.Column)
+		}
+	}
+	// walking forward should keep up
+	for !l.Terminated {
+		expect("Step")
+		l.Step()
+	}
+	// walking backward should too, even across blank lines
+	for l.Pos > 0 {
+		l.StepBack()
+		expect("StepBack")
+	}
+	// jumping back to a mark should restore the marked line and column
+	l.WalkUntil('t')
+	l.Mark()
+	l.WalkToEnd()
+	l.JumpToMark()
+	expect("JumpToMark")
+	// and setting the position by hand should still resolve correctly
+	l.Pos = 20
+	l.Step()
+	expect("Step after setting Pos")
+}
+
+func BenchmarkLexerWalk(b *testing.B) {
+	for _, lines := range []int{1000, 4000, 16000} {
+		input := []rune(strings.Repeat("<p class='line'>some text on a line</p>\n", lines))
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := NewLexer(input)
+				for !l.Terminated {
+					l.Step()
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*lines), "ns/line")
+		})
+	}
+}
+
+func TestLexerCheckpoints(t *testing.T) {
+	input := []rune("<p class='a b'>\n  %s name%\n</p>")
+	l := NewLexer(input)
+	// an outer scan over the whole tag
+	outer := l.Checkpoint()
+	l.WalkUntil('\'')
+	l.Step()
+	// a nested scan over the attribute value
+	inner := l.Checkpoint()
+	if l.Checkpoints() != 2 || inner.Depth != 2 {
+		t.Errorf(`expected two checkpoints but found %d`, l.Checkpoints())
+	}
+	l.WalkUntil('\'')
+	if string(l.CollectFromCheckpoint(inner)) != "a b'" {
+		t.Errorf(`expected inner checkpoint to collect "a b'" but got %s`, string(l.CollectFromCheckpoint(inner)))
+	}
+	// backtracking the inner scan must leave the outer one intact
+	l.Restore()
+	if l.Char() != "a" {
+		t.Errorf(`expected restore to land on "a" but landed on %s`, l.Char())
+	}
+	l.WalkUntil('>')
+	if string(l.CollectFromCheckpoint(outer)) != "<p class='a b'>" {
+		t.Errorf(`expected outer checkpoint to collect the whole tag but got %s`, string(l.CollectFromCheckpoint(outer)))
+	}
+	// committing keeps our position
+	l.Checkpoint()
+	l.WalkUntil('%')
+	l.Commit()
+	if l.Line != 2 || l.Column != 3 {
+		t.Errorf(`expected commit to keep us at 2:3 but we are at %d:%d`, l.Line, l.Column)
+	}
+	// restoring the outer checkpoint brings back its line and column too
+	l.Restore()
+	if l.Pos != 0 || l.Line != 1 || l.Column != 1 {
+		t.Errorf(`expected to be back at the start but we are at position %d (%d:%d)`, l.Pos, l.Line, l.Column)
+	}
+	if l.Restore() || l.Commit() {
+		t.Errorf(`expected an empty checkpoint stack`)
+	}
+}
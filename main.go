@@ -42,7 +42,7 @@ func main() {
 
 	err = parser.Walk(ast, func(n parser.Node) error {
 		logi.Log(n.GetInfo().Value)
-		logi.Log(n.GetInfo().TextContent)
+		logi.Log(parser.TextContent(n))
 		logi.Log(parser.GetAttribute(n, "type"))
 		return nil
 	})
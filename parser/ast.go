@@ -1,6 +1,10 @@
 package parser
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/phillip-england/gtml/token"
 )
 
@@ -12,136 +16,237 @@ func (elm *Document) GetInfo() *NodeInfo {
 	return elm.Info
 }
 
-func NewAst(toks []token.Token) (Node, error) {
-	var doc Node
-	doc = &Document{
+// NewAst builds a tree from toks. The tree is always built; problems with
+// how the tags nest are returned as token.Diagnostics.
+func NewAst(toks []token.Token, opts ...Option) (Node, error) {
+	o := newOptions(opts)
+	toks = token.ApplyWhitespace(toks, o.Whitespace)
+	doc := &Document{
 		Info: NewNodeInfo("", Root),
 	}
-	doc, err := firstPass(doc, toks)
-	if err != nil {
-		return doc, err
+	doc.Info.Span = token.SpanOf(toks)
+	b := newBuilder(toks)
+	b.implicit = o.ImplicitEndTags
+	b.build(doc)
+	if o.ImplicitEndTags {
+		imply(doc)
 	}
-	return doc, nil
+	return doc, b.diagnostics().Err()
+}
+
+// builder builds a tree from toks in a single pass, keeping the elements
+// that are still open on a stack. A close tag pops every element above the
+// open tag it matches; a close tag matching nothing on the stack is
+// stray and dropped. Elements still open at the end run to the last token.
+// With implicit set, start tags also close elements per impliedEnds.
+type builder struct {
+	toks     []token.Token
+	src      string // the source of toks, for slicing out element values
+	offsets  []int  // offset of each token within src, plus len(src)
+	stack    []openElement
+	open     map[string]int // elements on the stack, by tag name
+	implicit bool
+	problems []problem
 }
 
+// openElement is an element on the builder's stack and the index of its
+// start tag.
+type openElement struct {
+	n     *NodeNormal
+	name  string
+	start int
+}
 
-// FIRST PASS IS RUNNING FOREVER
-// NEED TO CREATE GOOD DOM HERE
+// problem is a diagnostic along with the index of the token it is about,
+// so they can be reported in source order.
+type problem struct {
+	at   int
+	diag token.Diagnostic
+}
+
+func newBuilder(toks []token.Token) *builder {
+	var src strings.Builder
+	offsets := make([]int, len(toks)+1)
+	for i, tok := range toks {
+		offsets[i] = src.Len()
+		src.WriteString(tok.GetLexeme())
+	}
+	offsets[len(toks)] = src.Len()
+	return &builder{
+		toks:    toks,
+		src:     src.String(),
+		offsets: offsets,
+		open:    map[string]int{},
+	}
+}
 
-func firstPass(n Node, toks []token.Token) (Node, error) {
-	switch n.GetInfo().Type {
-	case Normal:
-		innerToks, err := token.ShedOuterHtml(toks)
-		if err != nil {
-			return n, err
+// build appends the nodes for every token to root.
+func (b *builder) build(root Node) {
+	for i, tok := range b.toks {
+		if b.implicit && (tok.GetType() == token.HtmlOpen || tok.GetType() == token.HtmlVoid) {
+			b.closeImplied(i)
 		}
-		for i := 0; i < len(innerToks); {
-			tok := innerToks[i]
-			switch tok.GetType() {
-			case token.HtmlOpen:
-				_, endTagI, err := token.GetClosingTag(tok, i, innerToks)
-				if err != nil {
-					return n, err
-				}
-				child, err := firstPass(
-					NewNodeNormal(token.Construct(innerToks[i:endTagI+1]), Normal),
-					innerToks[i:endTagI+1],
-				)
-				if err != nil {
-					return n, err
-				}
-				AppendChild(n, child)
-				i = endTagI + 1
+		parent := root
+		if len(b.stack) > 0 {
+			parent = b.stack[len(b.stack)-1].n
+		}
+		switch tok.GetType() {
+		case token.HtmlOpen:
+			n := newNormal(tok)
+			AppendChild(parent, n)
+			name := token.GetTagName(tok)
+			b.stack = append(b.stack, openElement{n: n, name: name, start: i})
+			b.open[name]++
+		case token.HtmlClose:
+			name := token.GetTagName(tok)
+			if b.open[name] == 0 {
+				b.report(i, token.CodeStrayCloseTag, tok.GetSpan(), "%s has no matching open tag", tok.GetLexeme())
 				continue
-			case token.HtmlVoid:
-				child, err := firstPass(NewNodeVoid(tok.GetLexeme(), Void), []token.Token{tok})
-				if err != nil {
-					return n, err
+			}
+			for {
+				top := b.pop()
+				if top.name == name {
+					b.finish(top, i)
+					break
 				}
-				AppendChild(n, child)
-				i++
-				continue
-			// case lexer.EmptySpace:
-			// 	AppendTextNode(n, tok.GetLexeme())
-			// 	i++
-			// 	continue
-			case token.Text:
-				AppendTextNode(n, tok.GetLexeme())
-				i++
-				continue
-			default:
-				// Handle other tokens if necessary
-				i++
+				b.closeEarly(top, i)
 			}
+		case token.HtmlVoid:
+			AppendChild(parent, newVoid(tok))
+		case token.Text, token.RawText, token.EmptySpace:
+			AppendChild(parent, newText(tok))
+		case token.Comment, token.Doctype, token.CData:
+			AppendChild(parent, newMarkup(tok))
+		case token.Placeholder:
+			AppendChild(parent, newPlaceholder(tok))
 		}
-
-
-
-	case Root:
-		isSelfContained, err := token.IsSelfContained(toks)
-		if err != nil {
-			return n, err
+	}
+	for len(b.stack) > 0 {
+		top := b.pop()
+		if !b.implicit || !optionalEnd[strings.ToLower(top.name)] {
+			b.report(top.start, token.CodeUnclosedElement, b.toks[top.start].GetSpan(), "<%s> is not a void element and is never closed", token.RawTagName(b.toks[top.start]))
 		}
-		if isSelfContained {
-			child, err := firstPass(NewNodeNormal(token.Construct(toks), Normal), toks)
-			if err != nil {
-				return n, err
+		b.finish(top, len(b.toks)-1)
+	}
+}
+
+// closeImplied closes the elements that the start tag at i ends by
+// itself, such as an open <li> when another <li> starts. Tags in SVG and
+// MathML end nothing.
+func (b *builder) closeImplied(i int) {
+	if tag, ok := b.toks[i].(token.HtmlToken); ok && tag.Namespace != token.NamespaceHTML {
+		return
+	}
+	for _, rule := range impliedEnds[strings.ToLower(token.GetTagName(b.toks[i]))] {
+		k := -1
+		for j := len(b.stack) - 1; j >= 0; j-- {
+			name := strings.ToLower(b.stack[j].name)
+			if rule.closes[name] {
+				k = j
+			} else if rule.scope[name] || defaultScope[name] {
+				break
 			}
-			AppendChild(n, child)
-			break
 		}
-		for i := 0; i < len(toks); {
-			tok := toks[i]
-			switch tok.GetType() {
-			case token.HtmlOpen:
-				_, endTagI, err := token.GetClosingTag(tok, i, toks)
-				if err != nil {
-					return n, err
-				}
-				child, err := firstPass(
-					NewNodeNormal(token.Construct(toks[i:endTagI+1]), Normal),
-					toks[i:endTagI+1],
-				)
-				if err != nil {
-					return n, err
-				}
-				AppendChild(n, child)
-				i = endTagI + 1
-				continue
-			case token.HtmlVoid:
-				child, err := firstPass(NewNodeVoid(tok.GetLexeme(), Void), []token.Token{tok})
-				if err != nil {
-					return n, err
-				}
-				AppendChild(n, child)
-				i++
-				continue
-			// case lexer.EmptySpace:
-			// 	AppendTextNode(n, tok.GetLexeme())
-			// 	i++
-			// 	continue
-			case token.Text:
-				AppendTextNode(n, tok.GetLexeme())
-				i++
-				continue
-			default:
-				// Handle other tokens if necessary
-				i++
-			}
+		for k != -1 && len(b.stack) > k {
+			b.closeEarly(b.pop(), i)
 		}
 	}
-	return n, nil
 }
 
+// closeEarly ends an element just before the token at i, which is not its
+// own end tag. That is a problem unless its end tag may be left out.
+func (b *builder) closeEarly(e openElement, i int) {
+	if !b.implicit || !optionalEnd[strings.ToLower(e.name)] {
+		closer := b.toks[i]
+		b.report(e.start, token.CodeMismatchedTag, b.toks[e.start].GetSpan(), "<%s> is closed by %s at %s before its own end tag", token.RawTagName(b.toks[e.start]), closer.GetLexeme(), closer.GetSpan().Start)
+	}
+	b.finish(e, i-1)
+}
+
+// report records a nesting problem with the token at i.
+func (b *builder) report(i int, code string, span token.Span, format string, args ...any) {
+	b.problems = append(b.problems, problem{at: i, diag: token.Diagnostic{
+		Severity: token.SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	}})
+}
 
-func Walk(n Node, cb func(Node) error) error {
-	if err := cb(n); err != nil {
-		return err
+// diagnostics returns the problems found in source order.
+func (b *builder) diagnostics() token.Diagnostics {
+	slices.SortStableFunc(b.problems, func(x, y problem) int {
+		return x.at - y.at
+	})
+	diags := token.Diagnostics{}
+	for _, p := range b.problems {
+		diags = append(diags, p.diag)
 	}
-	for _, child := range n.GetInfo().Children {
-		if err := Walk(child, cb); err != nil {
-			return err
-		}
+	return diags
+}
+
+// pop removes the innermost open element from the stack.
+func (b *builder) pop() openElement {
+	top := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	b.open[top.name]--
+	return top
+}
+
+// finish sets the value and span of an element whose last token is at end.
+func (b *builder) finish(e openElement, end int) {
+	e.n.Info.Value = b.src[b.offsets[e.start]:b.offsets[end+1]]
+	e.n.Info.Span = token.SpanOf(b.toks[e.start : end+1])
+}
+
+// newNormal builds a Normal node from its start tag. Its value and span
+// are filled in once its end is known.
+func newNormal(tok token.Token) *NodeNormal {
+	return &NodeNormal{
+		Info:     NewNodeInfo(tok.GetLexeme(), Normal),
+		StartTag: startTagOf(tok),
+	}
+}
+
+// newVoid builds a Void node from a single token.
+func newVoid(tok token.Token) Node {
+	n := &NodeVoid{
+		Info:     NewNodeInfo(tok.GetLexeme(), Void),
+		StartTag: startTagOf(tok),
 	}
-	return nil
-}
\ No newline at end of file
+	n.Info.Span = tok.GetSpan()
+	return n
+}
+
+// newText builds a Text node from a run of text.
+func newText(tok token.Token) Node {
+	n := NewNodeText(tok.GetLexeme(), Text)
+	n.Info.Span = tok.GetSpan()
+	return n
+}
+
+// newMarkup builds a Comment, Doctype or CData node from its token.
+func newMarkup(tok token.Token) Node {
+	var n Node
+	switch tok.GetType() {
+	case token.Doctype:
+		n = NewNodeDoctype(tok.GetLexeme(), Doctype)
+	case token.CData:
+		n = NewNodeCData(tok.GetLexeme(), CData)
+	default:
+		n = NewNodeComment(tok.GetLexeme(), Comment)
+	}
+	n.GetInfo().Span = tok.GetSpan()
+	return n
+}
+
+// newPlaceholder builds a Placeholder node from its token.
+func newPlaceholder(tok token.Token) Node {
+	n := NewNodePlaceholder(tok.GetLexeme(), Placeholder)
+	n.Info.Span = tok.GetSpan()
+	if ph, ok := tok.(token.PlaceholderToken); ok {
+		n.Verb = ph.Verb
+		n.Expr = ph.Expr
+	}
+	return n
+}
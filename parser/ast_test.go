@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+// parse tokenizes and parses src, ignoring diagnostics.
+func parse(src string) Node {
+	toks, _ := token.TokenizeHtml([]rune(src))
+	doc, _ := NewAst(toks, Whitespace(token.WhitespacePreserve))
+	return doc
+}
+
+// shape writes the tree under n as nested tag names, e.g. "div(p(b))".
+func shape(n Node) string {
+	parts := []string{}
+	for _, child := range n.GetInfo().Children {
+		elm, ok := child.(*NodeNormal)
+		if !ok {
+			continue
+		}
+		parts = append(parts, elm.GetTagName()+"("+shape(elm)+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTreeBuilder(t *testing.T) {
+	tests := []struct {
+		src   string
+		shape string
+	}{
+		{`<div><p><b>x</b></p></div>`, "div(p(b()))"},
+		{`<div></span><p>x</p></div>`, "div(p())"},
+		{`<div><p><b>x</div><i>y</i>`, "div(p(b())) i()"},
+		{`<ul><li>a<li>b</ul>`, "ul(li(li()))"},
+		{`<div><span>`, "div(span())"},
+		{`</div></div><p></p>`, "p()"},
+		{`<a><b></a></b>`, "a(b())"},
+	}
+	for _, tt := range tests {
+		doc := parse(tt.src)
+		if got := shape(doc); got != tt.shape {
+			t.Errorf(`expected %s to build %s but it built %s`, tt.src, tt.shape, got)
+		}
+	}
+	// an element closed early ends at the token before the closing tag
+	doc := parse(`<div><p><b>x</div>`)
+	b := doc.GetInfo().Children[0].GetInfo().Children[0].GetInfo().Children[0]
+	if b.GetInfo().Value != "<b>x" {
+		t.Errorf(`expected <b> to run up to </div> but its value is %q`, b.GetInfo().Value)
+	}
+	div := doc.GetInfo().Children[0]
+	if div.GetInfo().Value != `<div><p><b>x</div>` || div.GetInfo().Span.End.Offset != 18 {
+		t.Errorf(`expected <div> to cover the whole source but got %q`, div.GetInfo().Value)
+	}
+}
+
+func TestTreeBuilderDeepNesting(t *testing.T) {
+	depth := 100000
+	src := strings.Repeat("<div>", depth) + "x" + strings.Repeat("</div>", depth)
+	doc := parse(src)
+	n := doc
+	for i := 0; i < depth; i++ {
+		children := n.GetInfo().Children
+		if len(children) != 1 {
+			t.Fatalf(`expected one child at depth %d but found %d`, i, len(children))
+		}
+		n = children[0]
+	}
+	if TextContent(n) != "x" {
+		t.Errorf(`expected the innermost div to hold x`)
+	}
+	// unclosed elements at the end are no deeper a problem
+	doc = parse(strings.Repeat("<div>", depth))
+	if doc.GetInfo().Children[0].GetInfo().Value != strings.Repeat("<div>", depth) {
+		t.Errorf(`expected the outer unclosed div to run to the end`)
+	}
+}
+
+// TestTreeBuilderAdversarial builds trees from random soups of tags and
+// checks every start tag becomes exactly one node and every node's span
+// sits inside its parent's.
+func TestTreeBuilderAdversarial(t *testing.T) {
+	fragments := []string{
+		"<div>", "</div>", "<p>", "</p>", "<b>", "</b>", "</i>", "<br>",
+		"<img src=x/>", "text", " ", "<!-- c -->", "%s name%", "<script>", "</script>",
+		"</", "<", ">", "<div", `"`,
+	}
+	check := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		var src strings.Builder
+		for i := r.Intn(200); i > 0; i-- {
+			src.WriteString(fragments[r.Intn(len(fragments))])
+		}
+		toks, _ := token.TokenizeHtml([]rune(src.String()))
+		doc, _ := NewAst(toks, Whitespace(token.WhitespacePreserve))
+		opens := 0
+		for _, tok := range toks {
+			if tok.GetType() == token.HtmlOpen {
+				opens++
+			}
+		}
+		normals := 0
+		ok := true
+		var visit func(n Node)
+		visit = func(n Node) {
+			for _, child := range n.GetInfo().Children {
+				if child.GetInfo().Type == Normal {
+					normals++
+				}
+				outer, inner := n.GetInfo().Span, child.GetInfo().Span
+				if inner.Start.Offset < outer.Start.Offset || inner.End.Offset > outer.End.Offset {
+					t.Logf(`%s at %s sits outside its parent at %s in %q`, child.GetInfo().Value, inner, outer, src.String())
+					ok = false
+				}
+				visit(child)
+			}
+		}
+		visit(doc)
+		if normals != opens {
+			t.Logf(`expected %d elements but built %d from %q`, opens, normals, src.String())
+			return false
+		}
+		return ok
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImplicitEndTags(t *testing.T) {
+	tests := []struct {
+		src   string
+		shape string
+	}{
+		{`<ul><li>a<li>b</ul>`, "ul(li() li())"},
+		{`<p>one<p>two`, "p() p()"},
+		{`<p>one<div>two</div>`, "p() div()"},
+		{`<ul><li>a<ul><li>b</ul><li>c</ul>`, "ul(li(ul(li())) li())"},
+		{`<dl><dt>a<dd>b<dt>c</dl>`, "dl(dt() dd() dt())"},
+		{`<table><thead><tr><th>a<tbody><tr><td>b<td>c<tr><td>d</table>`, "table(thead(tr(th())) tbody(tr(td() td()) tr(td())))"},
+		{`<select><option>a<optgroup><option>b<option>c</select>`, "select(option() optgroup(option() option()))"},
+		{`<button><p>a<div>b</div></button>`, "button(p() div())"},
+		{`<!DOCTYPE html><title>x</title><meta charset="utf-8"><p>hi`, "html(head(title()) body(p()))"},
+		{`<html><body><p>hi</body></html>`, "html(head() body(p()))"},
+	}
+	for _, tt := range tests {
+		toks, _ := token.TokenizeHtml([]rune(tt.src))
+		doc, err := NewAst(toks, ImplicitEndTags())
+		if err != nil {
+			t.Errorf(`expected %s to build without errors but got: %s`, tt.src, err)
+		}
+		if got := shape(doc); got != tt.shape {
+			t.Errorf(`expected %s to build %s but it built %s`, tt.src, tt.shape, got)
+		}
+		checkLinks(t, doc)
+	}
+	// fragments are not wrapped and other elements must still be closed
+	toks, _ := token.TokenizeHtml([]rune(`<div><b>x</div>`))
+	doc, err := NewAst(toks, ImplicitEndTags())
+	if shape(doc) != "div(b())" || err == nil {
+		t.Errorf(`expected an unclosed <b> to still be an error`)
+	}
+	// without the option only end tags close elements
+	toks, _ = token.TokenizeHtml([]rune(`<ul><li>a<li>b</ul>`))
+	doc, err = NewAst(toks)
+	if shape(doc) != "ul(li(li()))" || err == nil {
+		t.Errorf(`expected the list items to nest and be reported without the option`)
+	}
+}
+
+// TestTreeBuilderDiagnostics checks the builder reports nesting problems
+// exactly as token.CheckNesting does.
+func TestTreeBuilderDiagnostics(t *testing.T) {
+	for _, src := range []string{
+		`<div><p><b>x</div><i>y</i>`,
+		`<div></span><p>x</p>`,
+		`</div><a><b></a></b><ul><li>`,
+		`<p>ok</p>`,
+	} {
+		toks, _ := token.TokenizeHtml([]rune(src))
+		_, err := NewAst(toks)
+		want := token.CheckNesting(toks).Err()
+		if (err == nil) != (want == nil) || err != nil && err.Error() != want.Error() {
+			t.Errorf("expected %s to report\n%v\nbut got\n%v", src, want, err)
+		}
+	}
+}
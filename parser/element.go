@@ -1,7 +1,134 @@
 package parser
 
+import (
+	"slices"
+	"strings"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+// Element is a node with a start tag: a Normal or a Void node.
 type Element interface {
+	Node
 	GetTagName() string
-	GetAttiribute(name string) (Attribute, bool)
+	GetRawTagName() string
+	GetNamespace() token.Namespace
+	GetAttribute(name string) (Attribute, bool)
 	GetAttributes() []Attribute
-}
\ No newline at end of file
+	SetTagName(name string)
+	SetAttribute(name, value string)
+	SetAttributes(attrs []Attribute)
+	RemoveAttribute(name string) bool
+}
+
+// StartTag is the parsed start tag of an element. TagName is normalized as
+// token.GetTagName does, for matching, while RawTagName keeps the case it
+// was written in, for display. Namespace says whether the element is HTML,
+// SVG or MathML. Changing it does not change the node's Value, which keeps
+// the source as it was parsed.
+type StartTag struct {
+	TagName    string
+	RawTagName string
+	Namespace  token.Namespace
+	Attrs      []Attribute
+}
+
+// parseStartTag reads the tag name and attributes of the first tag in s,
+// which begins at start.
+func parseStartTag(s string, start token.Position) StartTag {
+	tok := token.HtmlToken{Lexeme: s, Type: token.HtmlOpen}
+	attrs, _ := token.ParseAttributes(s, start)
+	return StartTag{
+		TagName:    token.GetTagName(tok),
+		RawTagName: token.RawTagName(tok),
+		Attrs:      attrs,
+	}
+}
+
+// startTagOf reads the start tag of an element from its token. The
+// tokenizer has already parsed the attributes and namespace of an
+// HtmlToken, so those are only copied.
+func startTagOf(tok token.Token) StartTag {
+	tag, ok := tok.(token.HtmlToken)
+	if !ok {
+		return parseStartTag(tok.GetLexeme(), tok.GetSpan().Start)
+	}
+	return StartTag{
+		TagName:    token.GetTagName(tag),
+		RawTagName: token.RawTagName(tag),
+		Namespace:  tag.Namespace,
+		Attrs:      slices.Clone(tag.Attrs),
+	}
+}
+
+func (t *StartTag) GetTagName() string {
+	return t.TagName
+}
+
+func (t *StartTag) GetRawTagName() string {
+	return t.RawTagName
+}
+
+func (t *StartTag) GetNamespace() token.Namespace {
+	return t.Namespace
+}
+
+// GetAttribute returns the first attribute named name, ignoring case as
+// HTML does.
+func (t *StartTag) GetAttribute(name string) (Attribute, bool) {
+	for _, attr := range t.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
+
+func (t *StartTag) GetAttributes() []Attribute {
+	return t.Attrs
+}
+
+// SetTagName renames the element, normalizing name as the parser would.
+func (t *StartTag) SetTagName(name string) {
+	t.RawTagName = name
+	t.TagName = token.GetTagName(token.HtmlToken{Lexeme: "<" + name + ">", Type: token.HtmlOpen})
+}
+
+// SetAttribute sets the value of the attribute named name, adding it to
+// the end of the tag if it is not there yet.
+func (t *StartTag) SetAttribute(name, value string) {
+	attr := token.NewAttribute(name, value)
+	for i := range t.Attrs {
+		if strings.EqualFold(t.Attrs[i].Name, name) {
+			attr.Name = t.Attrs[i].Name
+			attr.Span = t.Attrs[i].Span
+			t.Attrs[i] = attr
+			return
+		}
+	}
+	t.Attrs = append(t.Attrs, attr)
+}
+
+func (t *StartTag) SetAttributes(attrs []Attribute) {
+	t.Attrs = attrs
+}
+
+// RemoveAttribute removes every attribute named name and reports whether
+// there was one.
+func (t *StartTag) RemoveAttribute(name string) bool {
+	kept := t.Attrs[:0]
+	for _, attr := range t.Attrs {
+		if !strings.EqualFold(attr.Name, name) {
+			kept = append(kept, attr)
+		}
+	}
+	removed := len(kept) != len(t.Attrs)
+	t.Attrs = kept
+	return removed
+}
+
+// clone copies the tag so the copy's attributes can change on their own.
+func (t StartTag) clone() StartTag {
+	t.Attrs = slices.Clone(t.Attrs)
+	return t
+}
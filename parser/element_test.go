@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+func TestElements(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<form class="a b" data-x="a=b"><input type='text'
+	disabled></form>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	form, ok := doc.GetInfo().Children[0].(Element)
+	if !ok {
+		t.Fatalf(`expected the form to be an Element`)
+	}
+	if form.GetTagName() != "form" {
+		t.Errorf(`expected the tag name form but got %s`, form.GetTagName())
+	}
+	if attr, ok := form.GetAttribute("data-x"); !ok || attr.Value != "a=b" {
+		t.Errorf(`expected data-x to be a=b but got %q`, attr.Value)
+	}
+	input, ok := form.GetInfo().Children[0].(Element)
+	if !ok {
+		t.Fatalf(`expected the input to be an Element`)
+	}
+	attrs := input.GetAttributes()
+	if len(attrs) != 2 || attrs[0].Value != "text" || !attrs[1].Boolean {
+		t.Errorf(`expected type='text' and disabled but got %v`, attrs)
+	}
+	if attrs[1].Span.Start.Line != 2 {
+		t.Errorf(`expected disabled on line 2 but it is on line %d`, attrs[1].Span.Start.Line)
+	}
+	input.SetAttribute("type", "email")
+	input.SetAttribute("name", `say "hi"`)
+	if attr, _ := GetAttribute(input, "TYPE"); attr.Value != "email" || attr.Raw != `"email"` {
+		t.Errorf(`expected type to be set to email but got %s`, attr)
+	}
+	if attr, _ := input.GetAttribute("name"); attr.Raw != `'say "hi"'` {
+		t.Errorf(`expected a value holding double quotes to be single quoted but got %s`, attr.Raw)
+	}
+	if tag, _ := toks[1].(token.HtmlToken); tag.Attrs[0].Value != "text" {
+		t.Errorf(`expected setting an attribute to leave the token alone`)
+	}
+	input.SetTagName("textarea")
+	if input.GetTagName() != "textarea" {
+		t.Errorf(`expected the tag name to be set to textarea`)
+	}
+	if _, ok := GetAttribute(doc, "class"); ok {
+		t.Errorf(`expected the document to have no attributes`)
+	}
+}
+
+func TestTagNameCase(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune("<DIV\n  class=\"x\"><Span>hi</SPAN><svg><clipPath id=\"c\"></clippath></svg></div>"))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		t.Fatalf(`expected mixed-case tags to nest cleanly but got: %s`, err)
+	}
+	div := doc.GetInfo().FirstChild().(Element)
+	if div.GetTagName() != "div" || div.GetRawTagName() != "DIV" {
+		t.Errorf(`expected div written as DIV but got %s written as %s`, div.GetTagName(), div.GetRawTagName())
+	}
+	if found, _ := QueryAll(doc, "div > span"); len(found) != 1 {
+		t.Errorf(`expected to find the span by its lowercased name`)
+	}
+	clip, _ := Query(doc, "clipPath")
+	if clip == nil || clip.GetTagName() != "clipPath" {
+		t.Errorf(`expected clipPath to keep its camel case`)
+	}
+	var b strings.Builder
+	Render(&b, doc)
+	if b.String() != `<DIV class="x"><Span>hi</Span><svg><clipPath id="c"></clipPath></svg></DIV>` {
+		t.Errorf(`expected tags to render as written but got %s`, b.String())
+	}
+	div.SetTagName("SECTION")
+	if div.GetTagName() != "section" || div.GetRawTagName() != "SECTION" {
+		t.Errorf(`expected a new tag name to be normalized too`)
+	}
+}
+
+func TestElementsFromTokens(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune("<p>\n<a href=\"%s url%\" title=x>y</a><br class=z></p>"))
+	if err != nil {
+		panic(err)
+	}
+	// the tree takes the attributes the tokenizer parsed rather than its own
+	a := toks[2].(token.HtmlToken)
+	a.Attrs[1].Value = "from the token"
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	link, _ := Query(doc, "a")
+	if attr, _ := link.GetAttribute("title"); attr.Value != "from the token" {
+		t.Errorf(`expected the title from the token but got %s`, attr.Value)
+	}
+	if attr, _ := link.GetAttribute("href"); len(attr.Parts) != 1 || attr.Parts[0].GetType() != token.Placeholder || attr.Span.Start.Line != 2 {
+		t.Errorf(`expected href on line 2 with its placeholder but got %+v`, attr)
+	}
+	br, _ := Query(doc, "br")
+	if attr, _ := br.GetAttribute("class"); attr.Span.Start.Column != 36 {
+		t.Errorf(`expected class at column 36 but it is at %d`, attr.Span.Start.Column)
+	}
+	// a node built on its own counts from the start of its source
+	input := NewNodeVoid("<input\n  name=\"q\">", Void)
+	if attr, _ := input.GetAttribute("name"); attr.Span.Start.Line != 2 || attr.Span.Start.Column != 3 {
+		t.Errorf(`expected name at 2:3 but it is at %s`, attr.Span.Start)
+	}
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// impliedRule says which open elements a start tag closes: the outermost
+// element in closes that is reached, searching down the stack, before an
+// element in scope or defaultScope.
+type impliedRule struct {
+	closes map[string]bool
+	scope  map[string]bool
+}
+
+func nameSet(names string) map[string]bool {
+	m := map[string]bool{}
+	for _, name := range strings.Fields(names) {
+		m[name] = true
+	}
+	return m
+}
+
+// defaultScope bounds every search for an element to close, as HTML's
+// "has an element in scope" does.
+var defaultScope = nameSet("applet caption html table td th marquee object template")
+
+// closesP is the rule for start tags that end an open paragraph.
+var closesP = impliedRule{closes: nameSet("p"), scope: nameSet("button")}
+
+// impliedEnds lists, by start tag, the rules for the elements it closes,
+// following HTML's optional end tags.
+var impliedEnds = map[string][]impliedRule{
+	"li":       {{closes: nameSet("li"), scope: nameSet("ul ol")}, closesP},
+	"dt":       {{closes: nameSet("dt dd"), scope: nameSet("dl")}, closesP},
+	"dd":       {{closes: nameSet("dt dd"), scope: nameSet("dl")}, closesP},
+	"tr":       {{closes: nameSet("tr td th"), scope: nameSet("thead tbody tfoot")}},
+	"td":       {{closes: nameSet("td th"), scope: nameSet("tr")}},
+	"th":       {{closes: nameSet("td th"), scope: nameSet("tr")}},
+	"thead":    {{closes: nameSet("thead tbody tfoot tr td th"), scope: nameSet("")}},
+	"tbody":    {{closes: nameSet("thead tbody tfoot tr td th"), scope: nameSet("")}},
+	"tfoot":    {{closes: nameSet("thead tbody tfoot tr td th"), scope: nameSet("")}},
+	"option":   {{closes: nameSet("option"), scope: nameSet("select datalist optgroup")}},
+	"optgroup": {{closes: nameSet("option optgroup"), scope: nameSet("select datalist")}},
+}
+
+func init() {
+	blocks := "address article aside blockquote details dialog div dl fieldset figcaption figure footer form " +
+		"h1 h2 h3 h4 h5 h6 header hgroup hr main menu nav ol p pre section summary table ul"
+	for _, name := range strings.Fields(blocks) {
+		impliedEnds[name] = []impliedRule{closesP}
+	}
+}
+
+// optionalEnd are the elements whose end tag may be left out.
+var optionalEnd = nameSet("p li dt dd tr td th thead tbody tfoot option optgroup html head body")
+
+// headContent are the elements that belong in an implied head.
+var headContent = nameSet("base link meta noscript script style template title")
+
+// imply gives a full document the html, head and body elements it leaves
+// out. A document is full when it has a doctype or any of those three
+// elements; component fragments are left alone. Implied elements have a
+// zero Span.
+func imply(doc Node) {
+	if !isFullDocument(doc) {
+		return
+	}
+	html := childElement(doc, "html")
+	if html == nil {
+		html = NewNodeNormal("<html>", Normal)
+		start := 0
+		for i, child := range doc.GetInfo().Children {
+			if child.GetInfo().Type == Doctype {
+				start = i + 1
+			}
+		}
+		children := slices.Clone(doc.GetInfo().Children[start:])
+		AppendChild(doc, html)
+		for _, child := range children {
+			AppendChild(html, child)
+		}
+	}
+	head := childElement(html, "head")
+	if head == nil {
+		head = NewNodeNormal("<head>", Normal)
+		moved := []Node{}
+		for _, child := range html.GetInfo().Children {
+			if !isHeadContent(child) {
+				break
+			}
+			moved = append(moved, child)
+		}
+		insertAt(html, 0, head)
+		for _, child := range moved {
+			AppendChild(head, child)
+		}
+	}
+	if childElement(html, "body") == nil {
+		body := NewNodeNormal("<body>", Normal)
+		children := slices.Clone(html.GetInfo().Children[head.GetInfo().Index()+1:])
+		AppendChild(html, body)
+		for _, child := range children {
+			AppendChild(body, child)
+		}
+	}
+}
+
+// isFullDocument reports whether doc is a whole page rather than a
+// fragment.
+func isFullDocument(doc Node) bool {
+	for _, child := range doc.GetInfo().Children {
+		if child.GetInfo().Type == Doctype {
+			return true
+		}
+	}
+	for elm := range Elements(doc) {
+		switch strings.ToLower(elm.GetTagName()) {
+		case "html", "head", "body":
+			return true
+		}
+	}
+	return false
+}
+
+// childElement returns the first child of n that is an element called
+// name.
+func childElement(n Node, name string) Node {
+	for _, child := range n.GetInfo().Children {
+		if elm, ok := child.(Element); ok && strings.EqualFold(elm.GetTagName(), name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// isHeadContent reports whether n can sit in a head: a head element, a
+// comment or whitespace.
+func isHeadContent(n Node) bool {
+	switch n.GetInfo().Type {
+	case Comment:
+		return true
+	case Text:
+		return strings.TrimSpace(n.GetInfo().Value) == ""
+	}
+	elm, ok := n.(Element)
+	return ok && headContent[strings.ToLower(elm.GetTagName())]
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The helpers in this file change a tree in place while keeping every
+// node's parent and index in step. A node's Span always says where it came
+// from in the source, so it is kept when a node moves; nodes made by these
+// helpers have a zero Span. Value is likewise left as it was parsed.
+
+// InsertBefore puts n just before ref among the children of ref's parent,
+// first removing it from wherever it was.
+func InsertBefore(ref, n Node) error {
+	parent, err := insertionParent(ref, n)
+	if err != nil {
+		return err
+	}
+	detach(n)
+	insertAt(parent, ref.GetInfo().index, n)
+	return nil
+}
+
+// InsertAfter puts n just after ref among the children of ref's parent,
+// first removing it from wherever it was.
+func InsertAfter(ref, n Node) error {
+	parent, err := insertionParent(ref, n)
+	if err != nil {
+		return err
+	}
+	detach(n)
+	insertAt(parent, ref.GetInfo().index+1, n)
+	return nil
+}
+
+// RemoveChild takes child out of parent, leaving it detached.
+func RemoveChild(parent, child Node) error {
+	if child.GetInfo().parent != parent {
+		return fmt.Errorf(`attempted to remove a node from a parent it does not belong to: %s`, child.GetInfo().Value)
+	}
+	detach(child)
+	return nil
+}
+
+// ReplaceWith puts n where old is and leaves old detached.
+func ReplaceWith(old, n Node) error {
+	if old == n {
+		return nil
+	}
+	if err := InsertBefore(old, n); err != nil {
+		return err
+	}
+	detach(old)
+	return nil
+}
+
+// Wrap puts wrapper where n is and moves n inside it as its last child.
+// The wrapper must be an element that can hold children.
+func Wrap(n, wrapper Node) error {
+	if !isContainer(wrapper) {
+		return fmt.Errorf(`attempted to wrap a node in a %s node: %s`, wrapper.GetInfo().Type, wrapper.GetInfo().Value)
+	}
+	if err := InsertBefore(n, wrapper); err != nil {
+		return err
+	}
+	AppendChild(wrapper, n)
+	return nil
+}
+
+// Unwrap replaces n with its children, leaving n detached and empty.
+func Unwrap(n Node) error {
+	parent := n.GetInfo().parent
+	if parent == nil {
+		return fmt.Errorf(`attempted to unwrap a node with no parent: %s`, n.GetInfo().Value)
+	}
+	at := n.GetInfo().index
+	children := slices.Clone(n.GetInfo().Children)
+	detach(n)
+	for i, child := range children {
+		detach(child)
+		insertAt(parent, at+i, child)
+	}
+	return nil
+}
+
+// SetAttribute sets an attribute of n, which must be an Element.
+func SetAttribute(n Node, name, value string) error {
+	elm, ok := n.(Element)
+	if !ok {
+		return fmt.Errorf(`attempted to set the attribute %s on a %s node`, name, n.GetInfo().Type)
+	}
+	elm.SetAttribute(name, value)
+	return nil
+}
+
+// RemoveAttribute removes an attribute of n and reports whether it was
+// there. Nodes other than elements have no attributes to remove.
+func RemoveAttribute(n Node, name string) bool {
+	elm, ok := n.(Element)
+	if !ok {
+		return false
+	}
+	return elm.RemoveAttribute(name)
+}
+
+// SetText replaces the children of n with a single NodeText, or sets the
+// text of n itself if it is a NodeText. Only the root and elements that
+// can hold children can hold text.
+func SetText(n Node, text string) error {
+	if n.GetInfo().Type == Text {
+		n.GetInfo().Value = text
+		return nil
+	}
+	if !isContainer(n) {
+		return fmt.Errorf(`attempted to set the text of a %s node: %s`, n.GetInfo().Type, n.GetInfo().Value)
+	}
+	for len(n.GetInfo().Children) > 0 {
+		detach(n.GetInfo().Children[0])
+	}
+	AppendTextNode(n, text)
+	return nil
+}
+
+// isContainer reports whether n can hold children.
+func isContainer(n Node) bool {
+	typ := n.GetInfo().Type
+	return typ == Root || typ == Normal
+}
+
+// Clone returns a deep copy of n. The copy is detached; its descendants
+// are copies too, linked to one another as in the original.
+func Clone(n Node) Node {
+	c := cloneNode(n)
+	for _, child := range n.GetInfo().Children {
+		AppendChild(c, Clone(child))
+	}
+	return c
+}
+
+// cloneNode copies n without its children or its place in the tree.
+func cloneNode(n Node) Node {
+	info := *n.GetInfo()
+	info.Children = make([]Node, 0, len(n.GetInfo().Children))
+	info.parent = nil
+	info.index = -1
+	switch n := n.(type) {
+	case *Document:
+		return &Document{Info: &info}
+	case *NodeNormal:
+		return &NodeNormal{Info: &info, StartTag: n.StartTag.clone()}
+	case *NodeVoid:
+		return &NodeVoid{Info: &info, StartTag: n.StartTag.clone()}
+	case *NodeText:
+		return &NodeText{Info: &info}
+	case *NodeComment:
+		return &NodeComment{Info: &info}
+	case *NodeDoctype:
+		return &NodeDoctype{Info: &info}
+	case *NodeCData:
+		return &NodeCData{Info: &info}
+	case *NodePlaceholder:
+		c := *n
+		c.Info = &info
+		return &c
+	}
+	panic(fmt.Sprintf(`attempted to clone a node of unknown type %T`, n))
+}
+
+// insertionParent returns the parent of ref, checking that n can be put
+// next to ref without making a node its own ancestor.
+func insertionParent(ref, n Node) (Node, error) {
+	parent := ref.GetInfo().parent
+	if parent == nil {
+		return nil, fmt.Errorf(`attempted to insert next to a node with no parent: %s`, ref.GetInfo().Value)
+	}
+	if ref == n {
+		return nil, fmt.Errorf(`attempted to insert a node next to itself: %s`, n.GetInfo().Value)
+	}
+	if parent == n || slices.Contains(parent.GetInfo().Ancestors(), n) {
+		return nil, fmt.Errorf(`attempted to insert a node inside itself: %s`, n.GetInfo().Value)
+	}
+	return parent, nil
+}
+
+// insertAt makes the detached node n the child of parent at index i.
+func insertAt(parent Node, i int, n Node) {
+	info := parent.GetInfo()
+	info.Children = slices.Insert(info.Children, i, n)
+	n.GetInfo().parent = parent
+	reindex(parent, i)
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+// checkLinks reports every node under n whose parent or index is out of
+// step with its parent's children.
+func checkLinks(t *testing.T, n Node) {
+	t.Helper()
+	for d := range All(n) {
+		for i, child := range d.GetInfo().Children {
+			if child.GetInfo().Parent() != d || child.GetInfo().Index() != i {
+				t.Errorf(`expected %q to be child %d of %q`, child.GetInfo().Value, i, d.GetInfo().Value)
+			}
+		}
+	}
+}
+
+func TestMutations(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<div><form _if="user"><input name="q"></form><p>a</p><p>b</p></div>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	div := doc.GetInfo().FirstChild()
+	form, _ := Query(doc, "form")
+	input, _ := Query(doc, "input")
+	pa, _ := Query(doc, "p")
+	pb := pa.GetInfo().NextSibling()
+
+	// inject a CSRF field at the start of every form
+	csrf := NewNodeVoid(`<input type="hidden" name="csrf">`, Void)
+	if err := InsertBefore(input, csrf); err != nil {
+		t.Fatalf(`expected to insert the csrf field but got: %s`, err)
+	}
+	if form.GetInfo().FirstChild() != csrf || input.GetInfo().Index() != 1 {
+		t.Errorf(`expected the csrf field before the input`)
+	}
+	// strip directive attributes
+	if !RemoveAttribute(form, "_if") || RemoveAttribute(form, "_if") {
+		t.Errorf(`expected _if to be removed exactly once`)
+	}
+	if err := SetAttribute(form, "method", "post"); err != nil {
+		t.Errorf(`expected to set the method but got: %s`, err)
+	}
+	if err := SetAttribute(pa.GetInfo().FirstChild(), "x", "y"); err == nil {
+		t.Errorf(`expected setting an attribute on text to fail`)
+	}
+	// move, replace, wrap and unwrap
+	if err := InsertAfter(pb, pa); err != nil || div.GetInfo().LastChild() != pa {
+		t.Errorf(`expected the first p to move to the end`)
+	}
+	span := NewNodeNormal("<span>", Normal)
+	if err := ReplaceWith(pb, span); err != nil || pb.GetInfo().Parent() != nil || span.GetInfo().Index() != 1 {
+		t.Errorf(`expected the span to take the place of the second p`)
+	}
+	section := NewNodeNormal("<section>", Normal)
+	if err := Wrap(form, section); err != nil || form.GetInfo().Parent() != section || div.GetInfo().FirstChild() != section {
+		t.Errorf(`expected the form to be wrapped in a section`)
+	}
+	if err := Unwrap(section); err != nil || div.GetInfo().FirstChild() != form || section.GetInfo().Parent() != nil {
+		t.Errorf(`expected unwrapping the section to put the form back`)
+	}
+	if err := SetText(span, "new"); err != nil || TextContent(span) != "new" {
+		t.Errorf(`expected the span to hold new`)
+	}
+	if err := SetText(input, "x"); err == nil {
+		t.Errorf(`expected setting the text of a void element to fail`)
+	}
+	if err := RemoveChild(div, pa); err != nil || pa.GetInfo().Parent() != nil {
+		t.Errorf(`expected the p to be removed`)
+	}
+	if err := RemoveChild(div, pa); err == nil {
+		t.Errorf(`expected removing a detached node to fail`)
+	}
+	checkLinks(t, doc)
+	tags := []string{}
+	for elm := range Elements(doc) {
+		tags = append(tags, elm.GetTagName())
+	}
+	if got := strings.Join(tags, " "); got != "div form input input span" {
+		t.Errorf(`expected div form input input span but got %s`, got)
+	}
+	// a node cannot be moved inside itself
+	if err := InsertBefore(input, form); err == nil {
+		t.Errorf(`expected inserting the form inside itself to fail`)
+	}
+	if err := InsertAfter(doc, span); err == nil {
+		t.Errorf(`expected inserting next to the document to fail`)
+	}
+	checkLinks(t, doc)
+}
+
+func TestMutationsNeedContainers(t *testing.T) {
+	doc := parse(`<!DOCTYPE html><p>a %s name%<!-- c --><![CDATA[d]]></p><br>`)
+	p, _ := Query(doc, "p")
+	br, _ := Query(doc, "br")
+	if err := Wrap(p, br); err == nil {
+		t.Errorf(`expected wrapping the p in a void element to fail`)
+	}
+	if p.GetInfo().Parent() != doc || len(br.GetInfo().Children) != 0 {
+		t.Errorf(`expected a failed wrap to leave the tree alone`)
+	}
+	leaves := 0
+	for n := range All(doc) {
+		switch n.GetInfo().Type {
+		case Comment, Doctype, CData, Placeholder:
+			leaves++
+			if err := SetText(n, "x"); err == nil {
+				t.Errorf(`expected setting the text of a %s node to fail`, n.GetInfo().Type)
+			}
+			if len(n.GetInfo().Children) != 0 {
+				t.Errorf(`expected a %s node to be left without children`, n.GetInfo().Type)
+			}
+		}
+	}
+	if leaves != 4 {
+		t.Errorf(`expected to try 4 nodes that cannot hold text but tried %d`, leaves)
+	}
+	if err := SetText(doc, "x"); err != nil || TextContent(doc) != "x" {
+		t.Errorf(`expected the document to hold x`)
+	}
+	checkLinks(t, doc)
+}
+
+func TestClone(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<ul class="a"><li>x %s name%</li><!-- c --><br></ul>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	ul := doc.GetInfo().FirstChild()
+	c := Clone(ul)
+	if c.GetInfo().Parent() != nil || c == ul {
+		t.Fatalf(`expected a detached copy`)
+	}
+	checkLinks(t, c)
+	original, copied := []Node{}, []Node{}
+	for n := range All(ul) {
+		original = append(original, n)
+	}
+	for n := range All(c) {
+		copied = append(copied, n)
+	}
+	if len(original) != len(copied) {
+		t.Fatalf(`expected %d nodes in the copy but got %d`, len(original), len(copied))
+	}
+	for i := range original {
+		if original[i] == copied[i] || original[i].GetInfo().Value != copied[i].GetInfo().Value || original[i].GetInfo().Span != copied[i].GetInfo().Span {
+			t.Errorf(`expected node %d to be copied`, i)
+		}
+	}
+	if ph, ok := copied[3].(*NodePlaceholder); !ok || ph.Expr != "name" {
+		t.Errorf(`expected the placeholder to be copied with its expression`)
+	}
+	SetAttribute(c, "class", "b")
+	if attr, _ := GetAttribute(ul, "class"); attr.Value != "a" {
+		t.Errorf(`expected changing the copy to leave the original alone`)
+	}
+}
@@ -3,73 +3,103 @@ package parser
 import (
 	"strings"
 
-	"github.com/phillip-england/gtml/stur"
+	"github.com/phillip-england/gtml/token"
 )
 
 type Node interface {
 	GetInfo() *NodeInfo
 }
 
+// AppendChild makes child the last child of parent, first removing it
+// from wherever it was in the tree.
 func AppendChild(parent Node, child Node) {
-	parent.GetInfo().Children = append(parent.GetInfo().Children, child)
+	detach(child)
+	info := parent.GetInfo()
+	info.Children = append(info.Children, child)
+	child.GetInfo().parent = parent
+	child.GetInfo().index = len(info.Children) - 1
 }
 
-func AppendTextNode(parent Node, text string) {
-	parent.GetInfo().TextContent = parent.GetInfo().TextContent+text
+// detach removes n from its parent's children, if it has a parent.
+func detach(n Node) {
+	info := n.GetInfo()
+	if info.parent == nil {
+		return
+	}
+	siblings := info.parent.GetInfo().Children
+	info.parent.GetInfo().Children = append(siblings[:info.index], siblings[info.index+1:]...)
+	reindex(info.parent, info.index)
+	info.parent = nil
+	info.index = -1
 }
 
-func GetAttributes(n Node) ([]Attribute) {
-	val := n.GetInfo().Value
-	val = strings.Replace(val, "<", "", 1)
-	val = strings.TrimSpace(val)
-	bits := strings.Split(val, " ")
-	if len(bits) == 1 || len(bits) == 0 {
-		return []Attribute{}
-	}
-	potentialAttrs := stur.SplitWithStringPreserve(strings.Join(bits, " "), ">")
-	potentialAttrs = strings.Split(potentialAttrs[0], " ")
-	if len(potentialAttrs) == 1 || len(potentialAttrs) == 0 {
-		return []Attribute{}
+// reindex renumbers the children of parent from index from onwards.
+func reindex(parent Node, from int) {
+	children := parent.GetInfo().Children
+	for i := from; i < len(children); i++ {
+		children[i].GetInfo().index = i
 	}
-	potentialAttrs = potentialAttrs[1:]
+}
 
-	attrs := []Attribute{}
-	for _, attr := range potentialAttrs {
-		if !strings.Contains(attr, "=") {
-			continue
-		}
-		parts := strings.Split(attr, "=")
-		if len(parts) == 2 {
-			name := parts[0]
-			val := parts[1]
-			attrs = append(attrs, Attribute{
-				Name: name,
-				Value: val,
-			})
-		}
-		if len(parts) == 1 {
-			attrs = append(attrs, Attribute{
-				Name: attr,
-				Value: "",
-			})
+// AppendTextNode appends a NodeText holding text as the last child of
+// parent.
+func AppendTextNode(parent Node, text string) {
+	AppendChild(parent, NewNodeText(text, Text))
+}
+
+// TextContent returns the text of n and its descendants in document
+// order. Placeholders are included as written, and each "%%" in text is
+// read as the '%' it escapes. Render leaves the escapes in place so that
+// its output tokenizes the same way again.
+func TextContent(n Node) string {
+	var b strings.Builder
+	Walk(n, func(d Node) error {
+		switch d.GetInfo().Type {
+		case Text:
+			if holdsPlaceholders(d.GetInfo().Parent()) {
+				b.WriteString(token.UnescapePercent(d.GetInfo().Value))
+			} else {
+				b.WriteString(d.GetInfo().Value)
+			}
+		case Placeholder:
+			b.WriteString(d.GetInfo().Value)
 		}
-	}
-	return attrs
+		return nil
+	})
+	return b.String()
 }
 
-type Attribute struct {
-	Name string
-	Value string
+// holdsPlaceholders reports whether text inside parent is read for
+// placeholders and "%%" escapes. Like the tokenizer, it leaves script
+// and style content alone.
+func holdsPlaceholders(parent Node) bool {
+	elm, ok := parent.(Element)
+	if !ok || elm.GetNamespace() != token.NamespaceHTML {
+		return true
+	}
+	name := elm.GetTagName()
+	return !token.IsRawTextElement(name) || name == "title" || name == "textarea"
 }
 
-func GetAttribute(n Node, attrName string) (Attribute, bool) {
-	attrs := GetAttributes(n)
-	for _, attr := range attrs {
-		if attr.Name == attrName {
-			return attr, true
-		}
+// GetAttributes returns the attributes of n in source order, or none if
+// n is not an Element.
+func GetAttributes(n Node) ([]Attribute) {
+	elm, ok := n.(Element)
+	if !ok {
+		return []Attribute{}
 	}
-	return Attribute{}, false
+	return elm.GetAttributes()
 }
 
+// Attribute is an attribute of an element's start tag.
+type Attribute = token.Attribute
 
+// GetAttribute returns the first attribute of n named attrName, ignoring
+// case as HTML does.
+func GetAttribute(n Node, attrName string) (Attribute, bool) {
+	elm, ok := n.(Element)
+	if !ok {
+		return Attribute{}, false
+	}
+	return elm.GetAttribute(attrName)
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+func TestMixedContent(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<p>Hello <b>you</b> there, %s name%!</p>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks, Whitespace(token.WhitespacePreserve))
+	if err != nil {
+		panic(err)
+	}
+	p := doc.GetInfo().Children[0]
+	expected := []struct {
+		typ   NodeType
+		value string
+	}{
+		{Text, "Hello "},
+		{Normal, "<b>you</b>"},
+		{Text, " there, "},
+		{Placeholder, "%s name%"},
+		{Text, "!"},
+	}
+	children := p.GetInfo().Children
+	if len(children) != len(expected) {
+		t.Fatalf(`expected %d children but got %d`, len(expected), len(children))
+	}
+	for i, want := range expected {
+		info := children[i].GetInfo()
+		if info.Type != want.typ || info.Value != want.value {
+			t.Errorf(`expected child %d to be %s %q but got %s %q`, i, want.typ, want.value, info.Type, info.Value)
+		}
+	}
+	if text, ok := children[0].(*NodeText); !ok || text.Info.Span.Start.Column != 4 {
+		t.Errorf(`expected the first child to be a NodeText at column 4`)
+	}
+	if text := TextContent(p); text != "Hello you there, %s name%!" {
+		t.Errorf(`expected the text content in document order but got %q`, text)
+	}
+	if text := TextContent(children[1]); text != "you" {
+		t.Errorf(`expected the text content of <b> to be you but got %q`, text)
+	}
+}
+
+func TestTextContentPercent(t *testing.T) {
+	doc := parse(`<p>50%% off %s item%</p><script>a %% b</script>`)
+	p, _ := Query(doc, "p")
+	if text := TextContent(p); text != "50% off %s item%" {
+		t.Errorf(`expected %%%% to read as %% but got %q`, text)
+	}
+	if text := p.GetInfo().FirstChild().GetInfo().Value; text != "50%% off " {
+		t.Errorf(`expected the text node to keep the escape as written but got %q`, text)
+	}
+	script, _ := Query(doc, "script")
+	if text := TextContent(script); text != "a %% b" {
+		t.Errorf(`expected script content to be left alone but got %q`, text)
+	}
+	if out := render(t, p); out != `<p>50%% off %s item%</p>` {
+		t.Errorf(`expected rendering to keep the escape but got %s`, out)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<form><div><input name="a"><input name="b"></div><button>go</button></form>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	form := doc.GetInfo().FirstChild()
+	div := form.GetInfo().FirstChild()
+	a := div.GetInfo().FirstChild()
+	b := div.GetInfo().LastChild()
+	if a.GetInfo().Parent() != div || div.GetInfo().Parent() != form || form.GetInfo().Parent() != doc {
+		t.Errorf(`expected every node to point at its parent`)
+	}
+	if doc.GetInfo().Parent() != nil || doc.GetInfo().Index() != -1 {
+		t.Errorf(`expected the document to have no parent`)
+	}
+	if a.GetInfo().NextSibling() != b || b.GetInfo().PrevSibling() != a || b.GetInfo().Index() != 1 {
+		t.Errorf(`expected the inputs to be siblings`)
+	}
+	if a.GetInfo().PrevSibling() != nil || b.GetInfo().NextSibling() != nil {
+		t.Errorf(`expected nothing before the first input or after the last`)
+	}
+	ancestors := b.GetInfo().Ancestors()
+	if len(ancestors) != 3 || ancestors[0] != div || ancestors[1] != form || ancestors[2] != doc {
+		t.Errorf(`expected the ancestors of an input to be div, form and the document`)
+	}
+	// moving a node keeps both its old and its new parent in step
+	button := form.GetInfo().LastChild()
+	AppendChild(button, a)
+	if div.GetInfo().FirstChild() != b || b.GetInfo().Index() != 0 || b.GetInfo().PrevSibling() != nil {
+		t.Errorf(`expected b to move up when a is moved out of the div`)
+	}
+	if a.GetInfo().Parent() != button || a.GetInfo().Index() != 1 || a.GetInfo().PrevSibling() != button.GetInfo().FirstChild() {
+		t.Errorf(`expected a to be the last child of the button`)
+	}
+	if text := NewNodeText("x", Text); text.Info.Parent() != nil || text.Info.NextSibling() != nil || text.Info.Index() != -1 {
+		t.Errorf(`expected a new node to be detached`)
+	}
+}
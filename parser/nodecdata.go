@@ -0,0 +1,16 @@
+package parser
+
+type NodeCData struct {
+	Info *NodeInfo
+}
+
+func (n *NodeCData) GetInfo() *NodeInfo {
+	return n.Info
+}
+
+func NewNodeCData(s string, t NodeType) Node {
+	info := NewNodeInfo(s, t)
+	return &NodeCData{
+		Info: info,
+	}
+}
@@ -0,0 +1,16 @@
+package parser
+
+type NodeComment struct {
+	Info *NodeInfo
+}
+
+func (n *NodeComment) GetInfo() *NodeInfo {
+	return n.Info
+}
+
+func NewNodeComment(s string, t NodeType) Node {
+	info := NewNodeInfo(s, t)
+	return &NodeComment{
+		Info: info,
+	}
+}
@@ -0,0 +1,16 @@
+package parser
+
+type NodeDoctype struct {
+	Info *NodeInfo
+}
+
+func (n *NodeDoctype) GetInfo() *NodeInfo {
+	return n.Info
+}
+
+func NewNodeDoctype(s string, t NodeType) Node {
+	info := NewNodeInfo(s, t)
+	return &NodeDoctype{
+		Info: info,
+	}
+}
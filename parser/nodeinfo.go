@@ -1,10 +1,17 @@
 package parser
 
+import "github.com/phillip-england/gtml/token"
+
+// NodeInfo holds what every node has. Children should only be changed
+// through AppendChild and the other helpers in this package, which keep
+// each child's parent and index in step.
 type NodeInfo struct {
 	Value string
 	Children []Node
 	Type NodeType
-	TextContent string
+	Span token.Span // Source range of the node, including its end tag.
+	parent Node
+	index int
 }
 
 func NewNodeInfo(val string, t NodeType) *NodeInfo {
@@ -12,5 +19,61 @@ func NewNodeInfo(val string, t NodeType) *NodeInfo {
 		Value: val,
 		Children: make([]Node, 0),
 		Type: t,
+		index: -1,
+	}
+}
+
+// Parent returns the node's parent, or nil for a root or a detached node.
+func (info *NodeInfo) Parent() Node {
+	return info.parent
+}
+
+// Index returns the node's position among its parent's children, or -1 if
+// it has no parent.
+func (info *NodeInfo) Index() int {
+	return info.index
+}
+
+// NextSibling returns the child of the node's parent that follows it.
+func (info *NodeInfo) NextSibling() Node {
+	if info.parent == nil {
+		return nil
+	}
+	siblings := info.parent.GetInfo().Children
+	if info.index+1 >= len(siblings) {
+		return nil
+	}
+	return siblings[info.index+1]
+}
+
+// PrevSibling returns the child of the node's parent that precedes it.
+func (info *NodeInfo) PrevSibling() Node {
+	if info.parent == nil || info.index == 0 {
+		return nil
+	}
+	return info.parent.GetInfo().Children[info.index-1]
+}
+
+func (info *NodeInfo) FirstChild() Node {
+	if len(info.Children) == 0 {
+		return nil
+	}
+	return info.Children[0]
+}
+
+func (info *NodeInfo) LastChild() Node {
+	if len(info.Children) == 0 {
+		return nil
+	}
+	return info.Children[len(info.Children)-1]
+}
+
+// Ancestors returns the node's parent, its parent's parent and so on up to
+// the root, nearest first.
+func (info *NodeInfo) Ancestors() []Node {
+	out := []Node{}
+	for p := info.parent; p != nil; p = p.GetInfo().parent {
+		out = append(out, p)
 	}
+	return out
 }
\ No newline at end of file
@@ -1,16 +1,22 @@
 package parser
 
+import "github.com/phillip-england/gtml/token"
+
 type NodeNormal struct {
 	Info *NodeInfo	
+	StartTag
 }
 
 func (n *NodeNormal) GetInfo() *NodeInfo {
 	return n.Info
 }
 
-func NewNodeNormal(s string, t NodeType) Node {
+// NewNodeNormal builds an element from the source of its start tag s. Its
+// attributes are parsed from s, so their spans count from the start of s.
+func NewNodeNormal(s string, t NodeType) *NodeNormal {
 	info := NewNodeInfo(s, t)
 	return &NodeNormal{
 		Info: info,
+		StartTag: parseStartTag(s, token.Position{Line: 1, Column: 1}),
 	}
 }
\ No newline at end of file
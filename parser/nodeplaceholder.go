@@ -0,0 +1,32 @@
+package parser
+
+import "github.com/phillip-england/gtml/token"
+
+// NodePlaceholder is a "%s name%" interpolation. Verb is the printf verb
+// and Expr the expression it formats.
+type NodePlaceholder struct {
+	Info *NodeInfo
+	Verb token.Verb
+	Expr string
+}
+
+func (n *NodePlaceholder) GetInfo() *NodeInfo {
+	return n.Info
+}
+
+func NewNodePlaceholder(s string, t NodeType) *NodePlaceholder {
+	info := NewNodeInfo(s, t)
+	return &NodePlaceholder{
+		Info: info,
+	}
+}
+
+// Sprint formats val the way the placeholder's verb says to.
+func (n *NodePlaceholder) Sprint(val any) string {
+	return n.Verb.Sprint(val)
+}
+
+// GoType returns the Go type of the prop the placeholder formats.
+func (n *NodePlaceholder) GoType() string {
+	return n.Verb.GoType()
+}
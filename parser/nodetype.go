@@ -6,4 +6,9 @@ const (
 	Root NodeType = "Root"
 	Void NodeType = "Void"
 	Normal NodeType = "Normal"
+	Comment NodeType = "Comment"
+	Doctype NodeType = "Doctype"
+	CData NodeType = "CData"
+	Placeholder NodeType = "Placeholder"
+	Text NodeType = "Text"
 )
\ No newline at end of file
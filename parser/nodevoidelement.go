@@ -1,16 +1,22 @@
 package parser
 
+import "github.com/phillip-england/gtml/token"
+
 type NodeVoid struct {
 	Info *NodeInfo	
+	StartTag
 }
 
 func (n *NodeVoid) GetInfo() *NodeInfo {
 	return n.Info
 }
 
-func NewNodeVoid(s string, t NodeType) Node {
+// NewNodeVoid builds an element from the source of its start tag s. Its
+// attributes are parsed from s, so their spans count from the start of s.
+func NewNodeVoid(s string, t NodeType) *NodeVoid {
 	info := NewNodeInfo(s, t)
 	return &NodeVoid{
 		Info: info,
+		StartTag: parseStartTag(s, token.Position{Line: 1, Column: 1}),
 	}
 }
\ No newline at end of file
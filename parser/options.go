@@ -0,0 +1,66 @@
+package parser
+
+import "github.com/phillip-england/gtml/token"
+
+// Options control how NewAst builds a tree.
+type Options struct {
+	// Whitespace is applied to the tokens before the tree is built, in the
+	// same way token.TokenizeHtml applies it. It defaults to
+	// token.WhitespaceDrop, leaving whitespace-only runs out of the tree.
+	Whitespace token.WhitespaceMode
+	// ImplicitEndTags closes elements whose end tag HTML lets you leave
+	// out, such as <li> and <p>, and adds the html, head and body elements
+	// a full document leaves out. Without it, only end tags close elements.
+	ImplicitEndTags bool
+}
+
+// Option changes a single setting in Options.
+type Option func(*Options)
+
+// Whitespace sets how whitespace in text is handled.
+func Whitespace(mode token.WhitespaceMode) Option {
+	return func(o *Options) {
+		o.Whitespace = mode
+	}
+}
+
+// ImplicitEndTags follows HTML's optional end tag rules when building.
+func ImplicitEndTags() Option {
+	return func(o *Options) {
+		o.ImplicitEndTags = true
+	}
+}
+
+func newOptions(opts []Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RenderOptions control how Render writes a tree. The zero value renders
+// compactly, adding no whitespace of its own.
+type RenderOptions struct {
+	// Indent, when set, pretty-prints the tree with one node per line and
+	// each level of nesting indented by Indent.
+	Indent string
+}
+
+// RenderOption changes a single setting in RenderOptions.
+type RenderOption func(*RenderOptions)
+
+// Pretty renders one node per line, indenting each level by indent.
+func Pretty(indent string) RenderOption {
+	return func(o *RenderOptions) {
+		o.Indent = indent
+	}
+}
+
+func newRenderOptions(opts []RenderOption) RenderOptions {
+	o := RenderOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// QueryAll returns the elements below n that match the CSS selector, in
+// document order. The selector sees the whole tree n belongs to, so
+// combinators may reach above n, but only descendants of n are returned.
+func QueryAll(n Node, selector string) ([]Element, error) {
+	sel, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return nil, err
+	}
+	m := mirrorTree(n)
+	out := []Element{}
+	for _, match := range cascadia.QueryAll(m.html[n], sel) {
+		out = append(out, m.nodes[match].(Element))
+	}
+	return out, nil
+}
+
+// Query returns the first element below n that matches the CSS selector,
+// or nil if none does.
+func Query(n Node, selector string) (Element, error) {
+	sel, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return nil, err
+	}
+	m := mirrorTree(n)
+	match := cascadia.Query(m.html[n], sel)
+	if match == nil {
+		return nil, nil
+	}
+	return m.nodes[match].(Element), nil
+}
+
+// mirror is a copy of a tree as x/net/html nodes, which is what the
+// selector engine matches against, with the links between the two.
+type mirror struct {
+	html  map[Node]*html.Node
+	nodes map[*html.Node]Node
+}
+
+// mirrorTree mirrors the whole tree that n belongs to.
+func mirrorTree(n Node) mirror {
+	root := n
+	for p := n.GetInfo().Parent(); p != nil; p = p.GetInfo().Parent() {
+		root = p
+	}
+	m := mirror{
+		html:  map[Node]*html.Node{},
+		nodes: map[*html.Node]Node{},
+	}
+	Visit(root, VisitFuncs{
+		OnEnter: func(d Node, path Path) error {
+			h := mirrorNode(d)
+			m.html[d] = h
+			m.nodes[h] = d
+			if len(path) > 1 {
+				m.html[path[len(path)-2]].AppendChild(h)
+			}
+			return nil
+		},
+	})
+	return m
+}
+
+// mirrorNode makes the x/net/html counterpart of a single node.
+func mirrorNode(n Node) *html.Node {
+	if elm, ok := n.(Element); ok {
+		h := &html.Node{
+			Type:      html.ElementNode,
+			Data:      strings.ToLower(elm.GetTagName()),
+			Namespace: string(elm.GetNamespace()),
+		}
+		for _, attr := range elm.GetAttributes() {
+			h.Attr = append(h.Attr, html.Attribute{
+				Key: strings.ToLower(attr.Name),
+				Val: attr.Value,
+			})
+		}
+		return h
+	}
+	switch n.GetInfo().Type {
+	case Root:
+		return &html.Node{Type: html.DocumentNode}
+	case Comment:
+		return &html.Node{Type: html.CommentNode, Data: n.GetInfo().Value}
+	case Doctype:
+		return &html.Node{Type: html.DoctypeNode, Data: n.GetInfo().Value}
+	}
+	return &html.Node{Type: html.TextNode, Data: n.GetInfo().Value}
+}
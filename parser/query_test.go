@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+func TestQuery(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<div id="app" class="page main">
+	<ul>
+		<li data-id="1">a</li>
+		<li>b</li>
+		<li data-id="3" class="last">c</li>
+	</ul>
+	<form><input name="q"><button>go</button></form>
+</div>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	tests := []struct {
+		selector string
+		expected string
+	}{
+		{"li", "a b c"},
+		{"ul > li[data-id]", "a c"},
+		{".last", "c"},
+		{"#app > ul > li:nth-child(2)", "b"},
+		{"div.page.main li", "a b c"},
+		{"li + li", "b c"},
+		{"li[data-id] ~ li", "b c"},
+		{`li[data-id="3"]`, "c"},
+		{"input + button", "go"},
+		{"ul, button", "abc go"},
+		{"span", ""},
+	}
+	for _, tt := range tests {
+		found, err := QueryAll(doc, tt.selector)
+		if err != nil {
+			t.Errorf(`expected %s to compile but got: %s`, tt.selector, err)
+			continue
+		}
+		texts := []string{}
+		for _, elm := range found {
+			texts = append(texts, strings.Join(strings.Fields(TextContent(elm)), ""))
+		}
+		if got := strings.Join(texts, " "); got != tt.expected {
+			t.Errorf(`expected %s to find %q but found %q`, tt.selector, tt.expected, got)
+		}
+	}
+	// queries start below n but may look above it
+	form, err := Query(doc, "form")
+	if err != nil || form == nil {
+		t.Fatalf(`expected to find the form`)
+	}
+	input, err := Query(form, "#app input")
+	if err != nil || input == nil || input.GetInfo().Parent() != form {
+		t.Errorf(`expected to find the input from the form`)
+	}
+	if found, _ := QueryAll(form, "form"); len(found) != 0 {
+		t.Errorf(`expected a query to leave out the node it starts from`)
+	}
+	if found, _ := Query(doc, "table"); found != nil {
+		t.Errorf(`expected no table`)
+	}
+	if _, err := QueryAll(doc, "li["); err == nil {
+		t.Errorf(`expected a bad selector to be an error`)
+	}
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+// Render writes n and its descendants to w as HTML. Attributes are always
+// written with double quotes, text and attribute values are escaped where
+// they would otherwise read as markup, and the content of raw-text and
+// whitespace-preserving elements is written exactly as it is.
+func Render(w io.Writer, n Node, opts ...RenderOption) error {
+	r := &renderer{w: w, o: newRenderOptions(opts)}
+	if r.o.Indent == "" {
+		r.compact(n)
+	} else {
+		r.pretty(n, 0)
+	}
+	return r.err
+}
+
+// renderer writes HTML, holding on to the first write error.
+type renderer struct {
+	w   io.Writer
+	o   RenderOptions
+	err error
+}
+
+func (r *renderer) write(s string) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = io.WriteString(r.w, s)
+}
+
+// compact writes n with no whitespace besides what the tree holds.
+func (r *renderer) compact(n Node) {
+	switch n.GetInfo().Type {
+	case Normal:
+		elm := n.(Element)
+		r.write(startTag(elm))
+		raw := elm.GetNamespace() == token.NamespaceHTML && token.IsRawTextElement(elm.GetTagName())
+		for _, child := range n.GetInfo().Children {
+			if raw && child.GetInfo().Type == Text {
+				r.write(child.GetInfo().Value)
+				continue
+			}
+			r.compact(child)
+		}
+		r.write("</" + elm.GetRawTagName() + ">")
+	case Void:
+		r.write(startTag(n.(Element)))
+	case Text:
+		r.write(escapeText(n.GetInfo().Value))
+	case Root:
+		for _, child := range n.GetInfo().Children {
+			r.compact(child)
+		}
+	default:
+		r.write(n.GetInfo().Value)
+	}
+}
+
+// pretty writes n on lines of its own, indented by depth. Elements holding
+// only text stay on one line, and elements whose content is raw text or
+// preserves whitespace are written compactly.
+func (r *renderer) pretty(n Node, depth int) {
+	indent := strings.Repeat(r.o.Indent, depth)
+	info := n.GetInfo()
+	switch info.Type {
+	case Root:
+		for _, child := range info.Children {
+			r.pretty(child, depth)
+		}
+	case Normal:
+		elm := n.(Element)
+		name := elm.GetRawTagName()
+		raw := elm.GetNamespace() == token.NamespaceHTML && token.IsRawTextElement(name)
+		if raw || token.IsPreservedElement(name) {
+			r.write(indent)
+			r.compact(n)
+			r.write("\n")
+			return
+		}
+		if isInline(n) {
+			var b strings.Builder
+			inline := &renderer{w: &b}
+			for _, child := range info.Children {
+				inline.compact(child)
+			}
+			r.write(indent + startTag(elm) + strings.TrimSpace(b.String()) + "</" + name + ">\n")
+			return
+		}
+		r.write(indent + startTag(elm) + "\n")
+		for _, child := range info.Children {
+			r.pretty(child, depth+1)
+		}
+		r.write(indent + "</" + name + ">\n")
+	case Void:
+		r.write(indent + startTag(n.(Element)) + "\n")
+	case Text:
+		if text := strings.TrimSpace(info.Value); text != "" {
+			r.write(indent + escapeText(text) + "\n")
+		}
+	default:
+		r.write(indent + info.Value + "\n")
+	}
+}
+
+// isInline reports whether n holds only text and placeholders.
+func isInline(n Node) bool {
+	for _, child := range n.GetInfo().Children {
+		if t := child.GetInfo().Type; t != Text && t != Placeholder {
+			return false
+		}
+	}
+	return true
+}
+
+// startTag writes the start tag of elm with its attributes normalized.
+// Void nodes that are not void elements, such as "<x-icon />", keep their
+// slash so they read back as standalone; in SVG and MathML it is written
+// as "/>", as those are usually written.
+func startTag(elm Element) string {
+	var b strings.Builder
+	b.WriteString("<" + elm.GetRawTagName())
+	for _, attr := range elm.GetAttributes() {
+		b.WriteString(" " + attr.Name)
+		if !attr.Boolean {
+			b.WriteString(`="` + escapeAttr(attr.Value) + `"`)
+		}
+	}
+	switch {
+	case elm.GetInfo().Type != Void:
+	case elm.GetNamespace() != token.NamespaceHTML:
+		b.WriteString("/")
+	case !token.IsVoidElement(elm.GetTagName()):
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// escapeText escapes the characters of text that would read as markup.
+// Values keep their character references from the source, so only a '&'
+// that does not already start one is escaped.
+func escapeText(s string) string {
+	return escape(s, "<>")
+}
+
+// escapeAttr escapes an attribute value for writing in double quotes.
+// Angle brackets are safe inside quotes and left alone so directive
+// values such as _if="a > b" stay readable.
+func escapeAttr(s string) string {
+	return escape(s, `"`)
+}
+
+func escape(s string, special string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '&' && !isCharRef(s[i:]):
+			b.WriteString("&amp;")
+		case c == '<' && strings.IndexByte(special, c) != -1:
+			b.WriteString("&lt;")
+		case c == '>' && strings.IndexByte(special, c) != -1:
+			b.WriteString("&gt;")
+		case c == '"' && strings.IndexByte(special, c) != -1:
+			b.WriteString("&quot;")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isCharRef reports whether s starts with a character reference such as
+// "&amp;", "&#38;" or "&#x26;".
+func isCharRef(s string) bool {
+	end := strings.IndexByte(s, ';')
+	if end < 2 {
+		return false
+	}
+	ref := s[1:end]
+	isDigits := func(s string, hex bool) bool {
+		for _, c := range s {
+			if !('0' <= c && c <= '9' || hex && ('a' <= c && c <= 'f' || 'A' <= c && c <= 'F')) {
+				return false
+			}
+		}
+		return s != ""
+	}
+	switch {
+	case strings.HasPrefix(ref, "#x") || strings.HasPrefix(ref, "#X"):
+		return isDigits(ref[2:], true)
+	case strings.HasPrefix(ref, "#"):
+		return isDigits(ref[1:], false)
+	}
+	for i, c := range ref {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+// render renders n, failing the test on a write error.
+func render(t *testing.T, n Node, opts ...RenderOption) string {
+	t.Helper()
+	var b strings.Builder
+	if err := Render(&b, n, opts...); err != nil {
+		t.Fatalf(`expected to render but got: %s`, err)
+	}
+	return b.String()
+}
+
+// sameTree reports the first difference between the trees under a and b,
+// comparing tag names, attributes and text with character references
+// decoded and whitespace-only text left out. Text is compared by its words
+// when words is set, since pretty-printing moves whitespace around.
+func sameTree(a, b Node, words bool) string {
+	kids := func(n Node) []Node {
+		out := []Node{}
+		for _, child := range n.GetInfo().Children {
+			if child.GetInfo().Type == Text && strings.TrimSpace(child.GetInfo().Value) == "" {
+				continue
+			}
+			out = append(out, child)
+		}
+		return out
+	}
+	text := func(n Node) string {
+		s := html.UnescapeString(n.GetInfo().Value)
+		if words {
+			return strings.Join(strings.Fields(s), " ")
+		}
+		return s
+	}
+	ia, ib := a.GetInfo(), b.GetInfo()
+	if ia.Type != ib.Type {
+		return string(ia.Type) + " became " + string(ib.Type) + ": " + ia.Value
+	}
+	if ea, ok := a.(Element); ok {
+		eb := b.(Element)
+		if ea.GetTagName() != eb.GetTagName() {
+			return "<" + ea.GetTagName() + "> became <" + eb.GetTagName() + ">"
+		}
+		aa, ab := ea.GetAttributes(), eb.GetAttributes()
+		if len(aa) != len(ab) {
+			return "the attributes of <" + ea.GetTagName() + "> changed"
+		}
+		for i := range aa {
+			if aa[i].Name != ab[i].Name || html.UnescapeString(aa[i].Value) != html.UnescapeString(ab[i].Value) {
+				return aa[i].String() + " became " + ab[i].String()
+			}
+		}
+	} else if ia.Type != Root && text(a) != text(b) {
+		return text(a) + " became " + text(b)
+	}
+	ka, kb := kids(a), kids(b)
+	if len(ka) != len(kb) {
+		return "the children of " + ia.Value + " changed"
+	}
+	for i := range ka {
+		if diff := sameTree(ka[i], kb[i], words); diff != "" {
+			return diff
+		}
+	}
+	return ""
+}
+
+func TestRender(t *testing.T) {
+	src := `<!DOCTYPE html><div class='a b' hidden data-x=plain title="say &quot;hi&quot;">` +
+		`Tom &amp; Jerry &lt;3<br><x-icon /><!-- note -->` +
+		`<script>if (a < b && c) { go("</div>") }</script>` +
+		`<pre>  keep
+   this</pre><p>Hi, %s name%!</p></div>`
+	toks, err := token.TokenizeHtml([]rune(src))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	expected := `<!DOCTYPE html><div class="a b" hidden data-x="plain" title="say &quot;hi&quot;">` +
+		`Tom &amp; Jerry &lt;3<br><x-icon /><!-- note -->` +
+		`<script>if (a < b && c) { go("</div>") }</script>` +
+		`<pre>  keep
+   this</pre><p>Hi, %s name%!</p></div>`
+	if got := render(t, doc); got != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, got)
+	}
+	// text and attributes set by hand are escaped
+	p, _ := Query(doc, "p")
+	SetText(p, `a < b & "c"`)
+	SetAttribute(p, "title", `x "y" & z`)
+	if got := render(t, p); got != `<p title="x &quot;y&quot; &amp; z">a &lt; b &amp; "c"</p>` {
+		t.Errorf(`expected the p to be escaped but got %s`, got)
+	}
+	pretty := render(t, doc.GetInfo().FirstChild().GetInfo().NextSibling(), Pretty("  "))
+	expected = `<div class="a b" hidden data-x="plain" title="say &quot;hi&quot;">
+  Tom &amp; Jerry &lt;3
+  <br>
+  <x-icon />
+  <!-- note -->
+  <script>if (a < b && c) { go("</div>") }</script>
+  <pre>  keep
+   this</pre>
+  <p title="x &quot;y&quot; &amp; z">a &lt; b &amp; "c"</p>
+</div>
+`
+	if pretty != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, pretty)
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob("../tests/components/*.html")
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		toks, _ := token.TokenizeHtml([]rune(string(src)))
+		doc, _ := NewAst(toks)
+		for _, opts := range [][]RenderOption{nil, {Pretty("\t")}} {
+			out := render(t, doc, opts...)
+			toks, _ := token.TokenizeHtml([]rune(out))
+			again, _ := NewAst(toks)
+			if diff := sameTree(doc, again, len(opts) > 0); diff != "" {
+				t.Errorf("expected %s to render to an equivalent tree but %s in:\n%s", path, diff, out)
+			}
+			if next := render(t, again, opts...); next != out {
+				t.Errorf("expected rendering %s to be stable but got\n%s\nthen\n%s", path, out, next)
+			}
+		}
+	}
+}
+
+func TestRenderForeignContent(t *testing.T) {
+	src, err := os.ReadFile("../tests/components/icon.t.html")
+	if err != nil {
+		panic(err)
+	}
+	toks, err := token.TokenizeHtml([]rune(string(src)))
+	if err != nil {
+		t.Fatalf(`expected icon.t.html to tokenize cleanly but got: %s`, err)
+	}
+	doc, err := NewAst(toks, Whitespace(token.WhitespacePreserve))
+	if err != nil {
+		t.Fatalf(`expected icon.t.html to nest cleanly but got: %s`, err)
+	}
+	if out := render(t, doc); out != string(src) {
+		t.Errorf("expected the svg to render exactly as written but got\n%s", out)
+	}
+	namespaces := map[string]token.Namespace{
+		"button":          token.NamespaceHTML,
+		"svg":             token.NamespaceSVG,
+		"clipPath":        token.NamespaceSVG,
+		"stop":            token.NamespaceSVG,
+		"foreignObject":   token.NamespaceSVG,
+		"foreignObject p": token.NamespaceHTML,
+		"math":            token.NamespaceMathML,
+		"none":            token.NamespaceMathML,
+	}
+	for selector, ns := range namespaces {
+		elm, _ := Query(doc, selector)
+		if elm == nil {
+			t.Errorf(`expected to find %s`, selector)
+			continue
+		}
+		if elm.GetNamespace() != ns {
+			t.Errorf(`expected %s in the %q namespace but it is in %q`, selector, ns, elm.GetNamespace())
+		}
+	}
+	path, _ := Query(doc, "path")
+	if path == nil || path.GetInfo().Type != Void || len(path.GetInfo().Children) != 0 {
+		t.Errorf(`expected the self-closing path to be a childless Void node`)
+	}
+	svg, _ := Query(doc, "svg")
+	if attr, _ := svg.GetAttribute("viewBox"); attr.Name != "viewBox" || attr.Value != "0 0 24 24" {
+		t.Errorf(`expected viewBox to keep its case`)
+	}
+	clone := Clone(svg)
+	if clone.(Element).GetNamespace() != token.NamespaceSVG {
+		t.Errorf(`expected a copy to keep its namespace`)
+	}
+	// svg title and style hold markup, so their text is escaped
+	doc = parse(`<svg><title>x</title><style>y</style></svg><title>z</title>`)
+	for _, selector := range []string{"svg title", "svg style", "svg + title"} {
+		elm, _ := Query(doc, selector)
+		SetText(elm, "a < b")
+	}
+	want := `<svg><title>a &lt; b</title><style>a &lt; b</style></svg><title>a < b</title>`
+	if out := render(t, doc); out != want {
+		t.Errorf("expected\n%s\nbut got\n%s", want, out)
+	}
+	if out := render(t, doc, Pretty("  ")); strings.Count(out, "&lt;") != 2 {
+		t.Errorf("expected pretty output to escape svg text too but got\n%s", out)
+	}
+	title, _ := Query(parse(render(t, doc)), "svg title")
+	if title == nil || len(title.GetInfo().Children) != 1 || title.GetInfo().FirstChild().GetInfo().Type != Text {
+		t.Errorf(`expected the svg title to read back as text`)
+	}
+}
+
+func TestRenderSpaceBetweenPlaceholders(t *testing.T) {
+	src := `<p>%s first% %s last%</p>`
+	toks, err := token.TokenizeHtml([]rune(src))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	if out := render(t, doc); out != src {
+		t.Errorf(`expected the space between placeholders to survive but got %s`, out)
+	}
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"errors"
+	"iter"
+)
+
+// SkipChildren can be returned by a walk callback or a Visitor's Enter to
+// skip the children of the node being visited.
+var SkipChildren = errors.New("skip children")
+
+// Stop can be returned by a walk callback or a Visitor to end the walk
+// early. The walk then returns nil.
+var Stop = errors.New("stop walking")
+
+// Path is the chain of nodes from where a walk started down to the node
+// being visited, which is last. It is reused as the walk goes on, so
+// callbacks that keep it must copy it.
+type Path []Node
+
+// Depth returns how far below the walk's starting node the visited node
+// is; the starting node has depth 0.
+func (p Path) Depth() int {
+	return len(p) - 1
+}
+
+// Visitor is called on entering a node, before its children, and on
+// leaving it, after them. Leave is called even if Enter returned
+// SkipChildren.
+type Visitor interface {
+	Enter(n Node, path Path) error
+	Leave(n Node, path Path) error
+}
+
+// VisitFuncs turns a pair of functions into a Visitor. Either may be nil.
+type VisitFuncs struct {
+	OnEnter func(n Node, path Path) error
+	OnLeave func(n Node, path Path) error
+}
+
+func (v VisitFuncs) Enter(n Node, path Path) error {
+	if v.OnEnter == nil {
+		return nil
+	}
+	return v.OnEnter(n, path)
+}
+
+func (v VisitFuncs) Leave(n Node, path Path) error {
+	if v.OnLeave == nil {
+		return nil
+	}
+	return v.OnLeave(n, path)
+}
+
+// Walk calls cb for n and its descendants in pre-order.
+func Walk(n Node, cb func(Node) error) error {
+	return Visit(n, VisitFuncs{
+		OnEnter: func(n Node, _ Path) error {
+			return cb(n)
+		},
+	})
+}
+
+// WalkPost calls cb for n and its descendants in post-order, children
+// before their parent.
+func WalkPost(n Node, cb func(Node) error) error {
+	return Visit(n, VisitFuncs{
+		OnLeave: func(n Node, _ Path) error {
+			return cb(n)
+		},
+	})
+}
+
+// Visit walks n and its descendants with v. It returns the first error
+// from v other than SkipChildren and Stop.
+func Visit(n Node, v Visitor) error {
+	err := visit(n, v, Path{})
+	if err == Stop {
+		return nil
+	}
+	return err
+}
+
+func visit(n Node, v Visitor, path Path) error {
+	path = append(path, n)
+	err := v.Enter(n, path)
+	if err != nil && err != SkipChildren {
+		return err
+	}
+	if err == nil {
+		for _, child := range n.GetInfo().Children {
+			if err := visit(child, v, path); err != nil {
+				return err
+			}
+		}
+	}
+	if err := v.Leave(n, path); err != SkipChildren {
+		return err
+	}
+	return nil
+}
+
+// All returns an iterator over n and its descendants in pre-order.
+func All(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		Walk(n, func(d Node) error {
+			if !yield(d) {
+				return Stop
+			}
+			return nil
+		})
+	}
+}
+
+// Elements returns an iterator over the elements among n and its
+// descendants in pre-order.
+func Elements(n Node) iter.Seq[Element] {
+	return func(yield func(Element) bool) {
+		for d := range All(n) {
+			if elm, ok := d.(Element); ok && !yield(elm) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/gtml/token"
+)
+
+func TestVisit(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<div><ul><li>a</li><li>b</li></ul><p>c</p></div>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	events := []string{}
+	name := func(n Node) string {
+		if elm, ok := n.(Element); ok {
+			return elm.GetTagName()
+		}
+		return n.GetInfo().Value
+	}
+	err = Visit(doc.GetInfo().FirstChild(), VisitFuncs{
+		OnEnter: func(n Node, path Path) error {
+			events = append(events, strings.Repeat(" ", path.Depth())+"+"+name(n))
+			if name(n) == "ul" {
+				return SkipChildren
+			}
+			return nil
+		},
+		OnLeave: func(n Node, path Path) error {
+			events = append(events, strings.Repeat(" ", path.Depth())+"-"+name(n))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf(`expected the visit to succeed but got: %s`, err)
+	}
+	expected := "+div,  +ul,  -ul,  +p,   +c,   -c,  -p, -div"
+	if got := strings.Join(events, ", "); got != expected {
+		t.Errorf("expected the events\n%s\nbut got\n%s", expected, got)
+	}
+	// post-order visits children first and Stop ends the walk quietly
+	order := []string{}
+	err = WalkPost(doc, func(n Node) error {
+		order = append(order, name(n))
+		if name(n) == "ul" {
+			return Stop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf(`expected Stop to end the walk without an error but got: %s`, err)
+	}
+	if got := strings.Join(order, " "); got != "a li b li ul" {
+		t.Errorf(`expected a post-order walk but got %s`, got)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	toks, err := token.TokenizeHtml([]rune(`<ul><li>a</li><!-- x --><li>b<br></li></ul>`))
+	if err != nil {
+		panic(err)
+	}
+	doc, err := NewAst(toks)
+	if err != nil {
+		panic(err)
+	}
+	count := 0
+	for range All(doc) {
+		count++
+	}
+	if count != 8 {
+		t.Errorf(`expected 8 nodes including the document but got %d`, count)
+	}
+	names := []string{}
+	for elm := range Elements(doc) {
+		names = append(names, elm.GetTagName())
+		if elm.GetTagName() == "br" {
+			break
+		}
+	}
+	if got := strings.Join(names, " "); got != "ul li li br" {
+		t.Errorf(`expected the elements in document order but got %s`, got)
+	}
+}
@@ -0,0 +1,169 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attribute is a single attribute of a start tag. Raw is the value as
+// written, quotes included, and Value is the value without its quotes.
+// Quote is the quote rune used, or 0 for an unquoted value. A Boolean
+// attribute such as disabled has no value at all.
+type Attribute struct {
+	Name    string
+	Raw     string
+	Value   string
+	Quote   rune
+	Boolean bool
+	Span    Span
+	// Parts splits a quoted value into Text and Placeholder tokens when it
+	// holds placeholders.
+	Parts []Token
+}
+
+// String returns the attribute as it would be written in a tag.
+func (a Attribute) String() string {
+	if a.Boolean {
+		return a.Name
+	}
+	return a.Name + "=" + a.Raw
+}
+
+// ParseAttributes reads the attributes of the first tag in src, given the
+// position of src[0]. Duplicate names are reported as warnings and the
+// first occurrence is the one that counts, as in a browser.
+func ParseAttributes(src string, start Position) ([]Attribute, Diagnostics) {
+	runes := []rune(src)
+	pos := positions(runes, start)
+	attrs := []Attribute{}
+	diags := Diagnostics{}
+	seen := map[string]bool{}
+	i := 0
+	if i < len(runes) && runes[i] == '<' {
+		i++
+	}
+	for i < len(runes) && !isAttrSpace(runes[i]) && runes[i] != '/' && runes[i] != '>' {
+		i++
+	}
+	for i < len(runes) {
+		for i < len(runes) && (isAttrSpace(runes[i]) || runes[i] == '/') {
+			i++
+		}
+		if i == len(runes) || runes[i] == '>' {
+			break
+		}
+		nameStart := i
+		i++
+		for i < len(runes) && !isAttrSpace(runes[i]) && !strings.ContainsRune("/>=", runes[i]) {
+			i++
+		}
+		attr := Attribute{Name: string(runes[nameStart:i]), Boolean: true}
+		end := i
+		j := i
+		for j < len(runes) && isAttrSpace(runes[j]) {
+			j++
+		}
+		if j < len(runes) && runes[j] == '=' {
+			j++
+			for j < len(runes) && isAttrSpace(runes[j]) {
+				j++
+			}
+			valueStart := j
+			if j < len(runes) && (runes[j] == '"' || runes[j] == '\'') {
+				attr.Quote = runes[j]
+				j++
+				for j < len(runes) && runes[j] != attr.Quote {
+					j++
+				}
+				switch {
+				case j < len(runes):
+					attr.Value = string(runes[valueStart+1 : j])
+					j++
+				case runes[j-1] == '>' && j-1 > valueStart:
+					// the quote is never closed, so the tag's own '>' ends the value
+					j--
+					attr.Value = string(runes[valueStart+1 : j])
+				default:
+					attr.Value = string(runes[valueStart+1 : j])
+				}
+			} else {
+				for j < len(runes) && !isAttrSpace(runes[j]) && runes[j] != '>' {
+					j++
+				}
+				attr.Value = string(runes[valueStart:j])
+			}
+			attr.Raw = string(runes[valueStart:j])
+			attr.Boolean = false
+			if attr.Quote != 0 {
+				valueEnd := valueStart + 1 + len([]rune(attr.Value))
+				parts, problems := splitPlaceholders(HtmlToken{
+					Lexeme: attr.Value,
+					Type:   Text,
+					Span:   Span{Start: pos[valueStart+1], End: pos[valueEnd]},
+				})
+				for _, part := range parts {
+					if part.GetType() == Placeholder {
+						attr.Parts = parts
+						break
+					}
+				}
+				diags = append(diags, problems...)
+			}
+			end = j
+			i = j
+		}
+		attr.Span = Span{Start: pos[nameStart], End: pos[end]}
+		key := strings.ToLower(attr.Name)
+		if seen[key] {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     CodeDuplicateAttribute,
+				Message:  fmt.Sprintf("attribute %s is set more than once; the first value is used", attr.Name),
+				Span:     attr.Span,
+			})
+		}
+		seen[key] = true
+		attrs = append(attrs, attr)
+	}
+	return attrs, diags
+}
+
+// GetAttribute returns the first attribute of tok named name, ignoring
+// case as HTML does.
+func GetAttribute(tok HtmlToken, name string) (Attribute, bool) {
+	for _, attr := range tok.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// isAttrSpace reports whether r separates attributes inside a tag.
+func isAttrSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
+
+// NewAttribute builds an attribute named name with the given value,
+// quoted with double quotes unless the value holds one and no single
+// quote. Quotes the value cannot avoid are written as &quot;.
+func NewAttribute(name, value string) Attribute {
+	quote := '"'
+	if strings.ContainsRune(value, '"') && !strings.ContainsRune(value, '\'') {
+		quote = '\''
+	}
+	raw := value
+	if quote == '"' {
+		raw = strings.ReplaceAll(value, `"`, "&quot;")
+	}
+	return Attribute{
+		Name:  name,
+		Raw:   string(quote) + raw + string(quote),
+		Value: value,
+		Quote: quote,
+	}
+}
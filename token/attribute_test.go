@@ -0,0 +1,76 @@
+package token
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	src := "<input class=\"a b\" data-x=\"a=b\"\n\tplaceholder='text' disabled\n  value=plain title=\"%s name%\">"
+	toks, err := TokenizeHtml([]rune(src))
+	if err != nil {
+		t.Fatalf(`expected the input to tokenize cleanly but got: %s`, err)
+	}
+	tag := toks[0].(HtmlToken)
+	expected := []Attribute{
+		{Name: "class", Raw: `"a b"`, Value: "a b", Quote: '"'},
+		{Name: "data-x", Raw: `"a=b"`, Value: "a=b", Quote: '"'},
+		{Name: "placeholder", Raw: `'text'`, Value: "text", Quote: '\''},
+		{Name: "disabled", Boolean: true},
+		{Name: "value", Raw: "plain", Value: "plain"},
+		{Name: "title", Raw: `"%s name%"`, Value: "%s name%", Quote: '"'},
+	}
+	if len(tag.Attrs) != len(expected) {
+		t.Fatalf(`expected %d attributes but got %d: %v`, len(expected), len(tag.Attrs), tag.Attrs)
+	}
+	for i, want := range expected {
+		got := tag.Attrs[i]
+		if got.Name != want.Name || got.Raw != want.Raw || got.Value != want.Value || got.Quote != want.Quote || got.Boolean != want.Boolean {
+			t.Errorf(`expected attribute %d to be %+v but got %+v`, i, want, got)
+		}
+		if src[got.Span.Start.Offset:got.Span.End.Offset] != got.String() {
+			t.Errorf(`expected the span of %s to cover it in the source but it covers %q`, got, src[got.Span.Start.Offset:got.Span.End.Offset])
+		}
+	}
+	if tag.Attrs[2].Span.Start.Line != 2 || tag.Attrs[4].Span.Start.Line != 3 {
+		t.Errorf(`expected attributes after a newline to sit on the following lines`)
+	}
+	if parts := tag.Attrs[5].Parts; len(parts) != 1 || parts[0].GetType() != Placeholder {
+		t.Errorf(`expected the title value to split into a placeholder but got %v`, parts)
+	}
+	if attr, ok := GetAttribute(tag, "CLASS"); !ok || attr.Value != "a b" {
+		t.Errorf(`expected attribute lookups to ignore case`)
+	}
+}
+
+func TestDuplicateAttributes(t *testing.T) {
+	tz := NewTokenizer(strings.NewReader(`<div id="a" class="x" ID="b"></div>`))
+	toks := []Token{}
+	for tok, err := range tz.All() {
+		if err != nil {
+			t.Fatalf(`expected the div to tokenize but got: %s`, err)
+		}
+		toks = append(toks, tok)
+	}
+	diags := tz.Diagnostics()
+	if len(diags) != 1 || diags[0].Code != CodeDuplicateAttribute || diags[0].Severity != SeverityWarning {
+		t.Fatalf(`expected one duplicate-attribute warning but got: %v`, diags)
+	}
+	if diags[0].Span.Start.Column != 23 {
+		t.Errorf(`expected the warning on the second id at column 23 but it is at %d`, diags[0].Span.Start.Column)
+	}
+	if diags.Err() != nil {
+		t.Errorf(`expected a duplicate attribute to be only a warning`)
+	}
+	if attr, _ := GetAttribute(toks[0].(HtmlToken), "id"); attr.Value != "a" {
+		t.Errorf(`expected the first id to win but got %s`, attr.Value)
+	}
+	// TokenizeHtml only fails on errors; warnings come from TokenizeHtmlDiagnostics
+	if _, err := TokenizeHtml([]rune(`<div id="a" ID="b"></div>`)); err != nil {
+		t.Errorf(`expected a duplicate attribute not to fail tokenizing but got: %s`, err)
+	}
+	_, diags = TokenizeHtmlDiagnostics([]rune(`<div id="a" ID="b"></div><p>50% off</p>`))
+	if len(diags) != 2 || diags[0].Code != CodeDuplicateAttribute || diags[1].Code != CodeMalformedPlaceholder {
+		t.Errorf(`expected a duplicate-attribute and a malformed-placeholder warning but got: %v`, diags)
+	}
+}
@@ -0,0 +1,116 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity says how serious a Diagnostic is.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Codes identifying each kind of Diagnostic.
+const (
+	CodeUnterminatedTag     = "unterminated-tag"
+	CodeUnterminatedQuote   = "unterminated-quote"
+	CodeUnterminatedComment = "unterminated-comment"
+	CodeUnclosedElement     = "unclosed-element"
+	CodeStrayCloseTag       = "stray-close-tag"
+	CodeMismatchedTag       = "mismatched-tag"
+	CodeDuplicateAttribute  = "duplicate-attribute"
+)
+
+// Diagnostic is a single problem found in the source.
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Span     Span
+}
+
+// Error renders the diagnostic on one line, e.g.
+// "3:5: error[stray-close-tag]: </div> has no matching open tag".
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Span.Start, d.Severity, d.Code, d.Message)
+}
+
+// Format renders the diagnostic followed by the source line it starts on,
+// with carets under the offending range.
+func (d Diagnostic) Format(src string) string {
+	lines := strings.Split(src, "\n")
+	line := d.Span.Start.Line
+	if line < 1 || line > len(lines) {
+		return d.Error()
+	}
+	text := []rune(strings.TrimSuffix(lines[line-1], "\r"))
+	start := min(max(d.Span.Start.Column-1, 0), len(text))
+	end := len(text)
+	if d.Span.End.Line == line {
+		end = min(d.Span.End.Column-1, len(text))
+	}
+	// keep tabs so the carets line up with the source above them
+	pad := []rune{}
+	for _, r := range text[:start] {
+		if r == '\t' {
+			pad = append(pad, '\t')
+		} else {
+			pad = append(pad, ' ')
+		}
+	}
+	gutter := strings.Repeat(" ", len(fmt.Sprint(line)))
+	return fmt.Sprintf("%s\n %d | %s\n %s | %s%s",
+		d.Error(),
+		line, string(text),
+		gutter, string(pad), strings.Repeat("^", max(end-start, 1)),
+	)
+}
+
+// Diagnostics is every problem found in a source. It is returned as an
+// error when at least one of them is an error.
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	msgs := make([]string, len(ds))
+	for i, d := range ds {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Format renders every diagnostic against the source, separated by blank lines.
+func (ds Diagnostics) Format(src string) string {
+	out := make([]string, len(ds))
+	for i, d := range ds {
+		out[i] = d.Format(src)
+	}
+	return strings.Join(out, "\n\n")
+}
+
+// HasErrors reports whether any diagnostic has SeverityError.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns ds as an error if it holds any errors, otherwise nil.
+func (ds Diagnostics) Err() error {
+	if ds.HasErrors() {
+		return ds
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	src := "<div class=\"card>\n\t<p>hello</i></p>\n</div>\n<!-- left open\n"
+	toks, err := TokenizeHtml([]rune(src), Strict())
+	var diags Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf(`expected Diagnostics but got %v`, err)
+	}
+	// every problem is reported, not just the first one
+	expected := []string{CodeUnterminatedQuote, CodeUnterminatedComment, CodeStrayCloseTag}
+	if len(diags) != len(expected) {
+		t.Fatalf(`expected %d diagnostics but got %d:\n%s`, len(expected), len(diags), diags.Format(src))
+	}
+	for i, code := range expected {
+		if diags[i].Code != code {
+			t.Errorf(`expected diagnostic %d to be %s but it was %s`, i, code, diags[i].Code)
+		}
+	}
+	// the error never dumps the whole input
+	if strings.Contains(err.Error(), "left open") {
+		t.Errorf(`expected the error to leave the source out but got: %s`, err)
+	}
+	// and tokenizing carried on past each problem
+	last := toks[len(toks)-1]
+	if last.GetType() != Comment || last.GetLexeme() != "<!-- left open\n" {
+		t.Errorf(`expected the unclosed comment to run to the end of the input but got %s %q`, last.GetType(), last.GetLexeme())
+	}
+	// the stray </i> is underlined on its own line
+	expectedFormat := "2:10: error[stray-close-tag]: </i> has no matching open tag\n" +
+		" 2 | \t<p>hello</i></p>\n" +
+		"   | \t        ^^^^"
+	if got := diags[2].Format(src); got != expectedFormat {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expectedFormat, got)
+	}
+	// a tag that never sees a '>' is read as text
+	toks, err = TokenizeHtml([]rune("<p>hi</p><a href"))
+	if err == nil || toks[len(toks)-1].GetType() != Text {
+		t.Errorf(`expected an unterminated tag to be recovered as text`)
+	}
+	if !strings.Contains(err.Error(), "1:10: error[unterminated-tag]") {
+		t.Errorf(`expected an unterminated tag diagnostic at 1:10 but got: %s`, err)
+	}
+	// the quote reported is the one in the tag, not one further on
+	toks, err = TokenizeHtml([]rune(`<a title=it's>x</a><p class='y'>z</p>`))
+	if !errors.As(err, &diags) || len(diags) != 1 || diags[0].Span.Start.Column != 12 {
+		t.Errorf(`expected an unterminated quote diagnostic at 1:12 but got: %v`, err)
+	}
+	if len(toks) != 6 || toks[0].GetLexeme() != `<a title=it's>` {
+		t.Errorf(`expected the tag to end at the first '>' and the rest to tokenize normally`)
+	}
+	// and the tag's '>' is not part of the value left open
+	toks, _ = TokenizeHtml([]rune(`<a href="x>y`))
+	if attr, _ := GetAttribute(toks[0].(HtmlToken), "href"); attr.Value != "x" || attr.Raw != `"x` {
+		t.Errorf(`expected href to hold x but got %s`, attr)
+	}
+	// stray '<'s are each read as text without scanning to the end again
+	src = strings.Repeat("a < b ", 100000)
+	toks, err = TokenizeHtml([]rune(src))
+	if !errors.As(err, &diags) || len(diags) != 100000 || len(toks) != 100001 {
+		t.Errorf(`expected every '<' to be an unterminated tag read as text`)
+	}
+}
@@ -0,0 +1,90 @@
+package token
+
+import "strings"
+
+// svgTagNames maps the lowercased names of camel-case SVG elements back to
+// their proper case, as HTML's parser does.
+var svgTagNames = map[string]string{}
+
+func init() {
+	for _, name := range []string{
+		"altGlyph", "altGlyphDef", "altGlyphItem", "animateColor", "animateMotion",
+		"animateTransform", "clipPath", "feBlend", "feColorMatrix", "feComponentTransfer",
+		"feComposite", "feConvolveMatrix", "feDiffuseLighting", "feDisplacementMap",
+		"feDistantLight", "feDropShadow", "feFlood", "feFuncA", "feFuncB", "feFuncG",
+		"feFuncR", "feGaussianBlur", "feImage", "feMerge", "feMergeNode", "feMorphology",
+		"feOffset", "fePointLight", "feSpecularLighting", "feSpotLight", "feTile",
+		"feTurbulence", "foreignObject", "glyphRef", "linearGradient", "radialGradient",
+		"textPath",
+	} {
+		svgTagNames[strings.ToLower(name)] = name
+	}
+}
+
+// Namespace is the namespace an element is in. HTML elements have the
+// empty namespace; SVG and MathML elements are foreign content, where
+// "<tag/>" always closes the element and text is never raw.
+type Namespace string
+
+const (
+	NamespaceHTML   Namespace = ""
+	NamespaceSVG    Namespace = "svg"
+	NamespaceMathML Namespace = "math"
+)
+
+// htmlIntegrationPoints are the foreign elements whose content is HTML
+// again, by the namespace they sit in.
+var htmlIntegrationPoints = map[Namespace]map[string]bool{
+	NamespaceSVG:    {"foreignObject": true, "desc": true, "title": true},
+	NamespaceMathML: {"mi": true, "mo": true, "mn": true, "ms": true, "mtext": true},
+}
+
+// namespaceStack follows which namespace the tags of a stream are in. It
+// only holds the elements that switch namespace for their content: svg,
+// math and the HTML integration points inside them.
+type namespaceStack []namespaceScope
+
+type namespaceScope struct {
+	name string
+	ns   Namespace // the namespace of the element's content
+}
+
+// current returns the namespace of content at this point in the stream.
+func (s namespaceStack) current() Namespace {
+	if len(s) == 0 {
+		return NamespaceHTML
+	}
+	return s[len(s)-1].ns
+}
+
+// visit moves past a tag token and returns the namespace of the element it
+// opens or closes.
+func (s *namespaceStack) visit(tok Token) Namespace {
+	name := GetTagName(tok)
+	ns := s.current()
+	switch name {
+	case "svg":
+		ns = NamespaceSVG
+	case "math":
+		ns = NamespaceMathML
+	}
+	if tok.GetType() == HtmlClose {
+		if len(*s) > 0 && (*s)[len(*s)-1].name == name {
+			*s = (*s)[:len(*s)-1]
+			if name != "svg" && name != "math" {
+				ns = s.current()
+			}
+		}
+		return ns
+	}
+	if IsSelfClosing(tok) {
+		return ns
+	}
+	switch {
+	case name == "svg" || name == "math":
+		*s = append(*s, namespaceScope{name: name, ns: ns})
+	case htmlIntegrationPoints[ns][name]:
+		*s = append(*s, namespaceScope{name: name, ns: NamespaceHTML})
+	}
+	return ns
+}
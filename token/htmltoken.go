@@ -1,28 +1,32 @@
 package token
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/phillip-england/gtml/lexer"
-	"github.com/phillip-england/gtml/stur"
-)
+import "strings"
 
 type HtmlTokenType string
 
 const (
-	// EmptySpace HtmlTokenType = "EmptySpace"
-	HtmlOpen  HtmlTokenType = "HtmlOpen"
-	HtmlClose HtmlTokenType = "HtmlClose"
-	HtmlVoid  HtmlTokenType = "HtmlVoid"
-	Text      HtmlTokenType = "Text"
+	EmptySpace  HtmlTokenType = "EmptySpace" // whitespace-only text
+	HtmlOpen    HtmlTokenType = "HtmlOpen"
+	HtmlClose   HtmlTokenType = "HtmlClose"
+	HtmlVoid    HtmlTokenType = "HtmlVoid"
+	Text        HtmlTokenType = "Text"
+	Comment     HtmlTokenType = "Comment"     // <!-- ... -->, or a bogus <!...> / <?...?>
+	Doctype     HtmlTokenType = "Doctype"     // <!DOCTYPE ...>
+	CData       HtmlTokenType = "CData"       // <![CDATA[ ... ]]>
+	RawText     HtmlTokenType = "RawText"     // content of script, style, textarea and title
+	Placeholder HtmlTokenType = "Placeholder" // %s name% interpolation
 )
 
 type HtmlToken struct {
 	Lexeme string
 	Type   HtmlTokenType
-	Line   int
-	Column int
+	Span   Span
+	// Namespace is the namespace of the element a tag opens or closes.
+	Namespace Namespace
+	// Attrs are the attributes of a start tag, in source order.
+	Attrs []Attribute
+	// Placeholders found in the quoted attribute values of a tag.
+	Placeholders []Token
 }
 
 // GetLexeme returns the string content of the token.
@@ -36,97 +40,75 @@ func (tok HtmlToken) GetType() HtmlTokenType {
 }
 
 func (tok HtmlToken) GetLine() int {
-	return tok.Line
+	return tok.Span.Start.Line
 }
 
 func (tok HtmlToken) GetColumn() int {
-	return tok.Column
+	return tok.Span.Start.Column
+}
+
+// GetSpan returns the source range the token was read from.
+func (tok HtmlToken) GetSpan() Span {
+	return tok.Span
 }
 
 // TokenizeHtml tokenizes a slice of runes representing HTML input
 // into a list of tokens through two passes: raw token extraction
 // and structural classification (e.g., identifying void elements).
-func TokenizeHtml(input []rune) ([]Token, error) {
-	err := validateTokenInput(input)
-	toks, err := firstPass(input)
-	if err != nil {
-		return toks, err
-	}
-	toks, err = secondPass(toks)
-	if err != nil {
-		return toks, err
+// Problems in the input do not stop tokenizing; if any of them is an
+// error, every one found is returned together as Diagnostics. Use
+// TokenizeHtmlDiagnostics to see warnings as well.
+func TokenizeHtml(input []rune, opts ...Option) ([]Token, error) {
+	if err := validateTokenInput(input); err != nil {
+		return nil, err
+	}
+	toks, diags := TokenizeHtmlDiagnostics(input, opts...)
+	return toks, diags.Err()
+}
+
+// TokenizeHtmlDiagnostics is like TokenizeHtml but returns every problem
+// found in the input, warnings included, whether or not any is an error.
+func TokenizeHtmlDiagnostics(input []rune, opts ...Option) ([]Token, Diagnostics) {
+	o := newOptions(opts)
+	toks, diags := firstPass(input, o)
+	toks = secondPass(toks)
+	if o.Strict {
+		diags = append(diags, CheckNesting(toks)...)
 	}
-	return toks, nil
+	return toks, diags
 }
 
 func validateTokenInput(input []rune) error {
 	return nil
 }
 
-// secondPass processes tokens from the first pass and determines if
-// HtmlOpen tokens are actually HtmlVoid (self-closing) by checking
-// for corresponding HtmlClose tags later in the sequence.
-func secondPass(toks []Token) ([]Token, error) {
+// secondPass processes tokens from the first pass and turns HtmlOpen tokens
+// for void elements into HtmlVoid.
+func secondPass(toks []Token) []Token {
 	out := []Token{}
-	for i, tok := range toks {
-		if tok.GetType() != HtmlOpen {
-			out = append(out, tok)
+	for _, tok := range toks {
+		if tok.GetType() == HtmlOpen && IsVoid(tok) {
+			out = append(out, asVoid(tok))
 			continue
 		}
-		closingTag, _, err := GetClosingTag(tok, i, toks)
-		if err != nil {
-			return out, err
-		}
-		if closingTag == nil {
-			out = append(out, HtmlToken{
-				Lexeme: tok.GetLexeme(),
-				Type:   HtmlVoid,
-				Line:   tok.GetLine(),
-				Column: tok.GetColumn(),
-			})
-		} else {
-			out = append(out, tok)
-		}
+		out = append(out, tok)
 	}
+	return out
+}
 
-	return out, nil
+// asVoid returns a copy of an HtmlOpen token as HtmlVoid.
+func asVoid(tok Token) Token {
+	h := tok.(HtmlToken)
+	h.Type = HtmlVoid
+	return h
 }
 
-// GetClosingTag searches for the matching HtmlClose token corresponding
-// to the given HtmlOpen token, respecting nesting depth.
-// Returns nil if no matching closing tag is found.
+// GetClosingTag finds the HtmlClose token that matches the HtmlOpen token
+// at index i. Returns nil if no matching closing tag is found. Callers
+// looking up many tags should build a MatchTable once and use its
+// ClosingTag method instead.
 func GetClosingTag(tok Token, i int, toks []Token) (Token, int, error) {
-	if tok.GetType() == HtmlVoid {
-		return nil, -1, nil
-	}
-	if tok.GetType() != HtmlOpen {
-		return tok, -1, fmt.Errorf(`attempted to extract the closing tag from an invalid token: %s`, tok.GetLexeme())
-	}
-	name := GetTagName(tok)
-	found := 1
-	for i1, tok1 := range toks {
-		if i1 <= i {
-			continue
-		}
-		if tok1.GetType() != HtmlOpen && tok1.GetType() != HtmlClose {
-			continue
-		}
-		name1 := GetTagName(tok1)
-		if name != name1 {
-			continue
-		}
-		if tok1.GetType() == HtmlOpen {
-			found += 1
-		}
-		if tok1.GetType() == HtmlClose {
-			found -= 1
-		}
-		if found == 0 && tok1.GetType() == HtmlClose {
-			return tok1, i1, nil
-		}
-	}
-	// failed to find a matching closing tag
-	return nil, -1, nil
+	return NewMatchTable(toks).ClosingTag(i, toks)
 }
 
 func IsSelfContained(toks []Token) (bool, error) {
@@ -140,129 +122,62 @@ func IsSelfContained(toks []Token) (bool, error) {
 	return true, nil
 }
 
-// Extracts the full element from a slice of tokens
+// Extracts the full element starting at index i from a slice of tokens
 // if a full element cannot be derived from the token set
-// an empty string will be returned
+// an empty string will be returned. Callers extracting many elements
+// should build a MatchTable once and use its FullElement method instead.
 func ExtractFullElement(tok Token, i int, toks []Token) (string, error) {
-	if len(toks) == 0 {
-		return "", nil
-	}
-	firstTok := toks[0]
-	_, endI, err := GetClosingTag(firstTok, i, toks)
-	if err != nil {
-		return "", err
-	}
-	if endI > len(toks)-1 {
-		return "", fmt.Errorf(`GetClosingTag resulted in an endI index which is out of bounds`)
-	}
-	subSlice := toks[0 : endI+1]
-	out := Construct(subSlice)
-	return out, nil
+	return NewMatchTable(toks).FullElement(i, toks)
 }
 
+// ShedOuterHtml returns the tokens inside the element that spans all of
+// toks, or toks unchanged if they are not a single element.
 func ShedOuterHtml(toks []Token) ([]Token, error) {
-	out := []Token{}
-	if len(toks) == 0 {
-		return toks, nil
-	}
-	firstTok := toks[0]
-	if firstTok.GetType() == HtmlClose {
-		return out, fmt.Errorf("you cannot shed the outerhtml of an html closing tag: %s", firstTok.GetType())
-	}
-	_, closeTagIndex, err := GetClosingTag(toks[0], 0, toks)
-	if err != nil {
-		return out, err
-	}
-	if closeTagIndex == len(toks)-1 {
-		toks = toks[1:]
-		toks = toks[:len(toks)-1]
-		return toks, nil
-	} else {
-		return toks, nil
-	}
+	return NewMatchTable(toks).ShedOuterHtml(toks)
 }
 
 // GetTagName extracts the tag name from an HtmlOpen or HtmlClose token's lexeme.
-// Strips angle brackets, slashes, and attributes, returning just the tag name.
+// HTML names are lowercased so tags pair up whatever their case; SVG names
+// such as clipPath are given their camel case instead.
 func GetTagName(tok Token) string {
+	name := RawTagName(tok)
+	lower := strings.ToLower(name)
+	if svg, ok := svgTagNames[lower]; ok {
+		return svg
+	}
+	return lower
+}
+
+// RawTagName returns the tag name of a tag token as it is written, for
+// display. The name ends at the first whitespace, '/' or '>'.
+func RawTagName(tok Token) string {
 	if HtmlTokenType(tok.GetType()) != HtmlOpen && HtmlTokenType(tok.GetType()) != HtmlClose && HtmlTokenType(tok.GetType()) != HtmlVoid {
 		return ""
 	}
-	s := tok.GetLexeme()
-	s = strings.Replace(s, "<", "", 1)
-	s = stur.ReplaceLast(s, '>', "")
-	s = stur.ReplaceLast(s, '/', "")
-	parts := strings.Split(s, " ")
-	for _, part := range parts {
-		sq := stur.Squeeze(part)
-		if sq != "" {
-			return part
-		}
+	s := strings.TrimPrefix(tok.GetLexeme(), "<")
+	s = strings.TrimPrefix(s, "/")
+	s = strings.TrimLeft(s, htmlSpace)
+	if i := strings.IndexAny(s, htmlSpace+"/>"); i != -1 {
+		s = s[:i]
 	}
-	return ""
+	return s
 }
 
+// htmlSpace are the characters HTML treats as whitespace.
+const htmlSpace = " \t\n\r\f"
+
 // firstPass performs an initial walk over the input runes and splits the input
-// into basic tokens: HtmlOpen, HtmlClose, Text, and EmptySpace.
-// It uses the lexer to handle quote-skipping and whitespace correctly.
-func firstPass(input []rune) ([]Token, error) {
+// into basic tokens: HtmlOpen, HtmlClose, Text, EmptySpace and the like,
+// applying the whitespace mode as it goes.
+func firstPass(input []rune, o Options) ([]Token, Diagnostics) {
 	toks := []Token{}
-	l := lexer.NewLexer(input)
+	t := newRuneTokenizer(input, o)
 	for {
-		if l.Terminated {
-			break
-		}
-		// avoid capturing the leading '<' as text
-		if l.Pos != 0 {
-			l.Mark()
-			l.WalkUntilSkipQuotes('<')
-			l.StepBack()
-			buf := string(l.FlushFromMark())
-			if len(stur.Squeeze(buf)) == 0 {
-				// OPTING OUT OF COLLECTING EMPTY SPACE
-				// toks = append(toks, HtmlToken{
-				// 	Lexeme: buf,
-				// 	Type:   EmptySpace,
-				// 	Line: l.Line,
-				// 	Column: l.Column,
-				// })
-			} else {
-				toks = append(toks, HtmlToken{
-					Lexeme: buf,
-					Type:   Text,
-					Line:   l.Line,
-					Column: l.Column - len(buf),
-				})
-			}
-			l.Step()
-		}
-		if l.CharIs("<") {
-			l.Mark()
-			found := l.WalkUntilSkipQuotes('>')
-			if !found {
-				return toks, fmt.Errorf(`SYNTAX ERROR: failed to close html element: %s`, string(input))
-			}
-			buf := string(l.FlushFromMark())
-			sq := stur.Squeeze(buf)
-			if len(sq) > 2 && sq[1] == '/' {
-				toks = append(toks, HtmlToken{
-					Lexeme: buf,
-					Type:   HtmlClose,
-					Line:   l.Line,
-					Column: l.Column - len(buf),
-				})
-			} else {
-				toks = append(toks, HtmlToken{
-					Lexeme: buf,
-					Type:   HtmlOpen,
-					Line:   l.Line,
-					Column: l.Column - len(buf),
-				})
-			}
-			l.Step()
-			continue
+		// the input is already in memory, so the only error is io.EOF
+		tok, err := t.next()
+		if err != nil {
+			return toks, t.Diagnostics()
 		}
-		l.Step()
+		toks = append(toks, tok)
 	}
-	return toks, nil
 }
@@ -2,6 +2,7 @@ package token
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -19,7 +20,8 @@ func TestHtmlToken(t *testing.T) {
 			</ul>
 		</form>
 	`)
-	toks, err := TokenizeHtml(form)
+	// whitespace is kept by default, so ask for it to be dropped
+	toks, err := TokenizeHtml(form, Whitespace(WhitespaceDrop))
 	if err != nil {
 		panic(err)
 	}
@@ -113,3 +115,193 @@ func TestHtmlToken(t *testing.T) {
 	fmt.Println(toks)
 
 }
+
+// BenchmarkTokenizeHtml tokenizes inputs of growing size. The ns/line
+// metric should stay roughly flat as the line count grows.
+func BenchmarkTokenizeHtml(b *testing.B) {
+	for _, lines := range []int{1000, 4000, 16000} {
+		input := []rune(strings.Repeat("<li class='item'>some text on a line</li>\n", lines))
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := TokenizeHtml(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*lines), "ns/line")
+		})
+	}
+}
+
+func TestTokenSpans(t *testing.T) {
+	input := "<p>\n  héllo\n  wörld</p>\n<br>"
+	toks, err := TokenizeHtml([]rune(input), Whitespace(WhitespaceDrop))
+	if err != nil {
+		panic(err)
+	}
+	if len(toks) != 4 {
+		t.Fatalf(`expected 4 toks but found %d`, len(toks))
+	}
+	// every span should slice its own lexeme back out of the source
+	for _, tok := range toks {
+		span := tok.GetSpan()
+		if got := input[span.Start.Offset:span.End.Offset]; got != tok.GetLexeme() {
+			t.Errorf(`expected span %s to cover %q but it covers %q`, span, tok.GetLexeme(), got)
+		}
+		if span.Len() != len([]rune(tok.GetLexeme())) {
+			t.Errorf(`expected span %s to be %d runes long but it is %d`, span, len([]rune(tok.GetLexeme())), span.Len())
+		}
+	}
+	// the multi-line text starts right after <p> and ends before </p>
+	text := toks[1].GetSpan()
+	if text.Start.Line != 1 || text.Start.Column != 4 {
+		t.Errorf(`expected text to start at 1:4 but it starts at %s`, text.Start)
+	}
+	if text.End.Line != 3 || text.End.Column != 8 {
+		t.Errorf(`expected text to end at 3:8 but it ends at %s`, text.End)
+	}
+	// the <br> sits on the fourth line
+	if toks[3].GetLine() != 4 || toks[3].GetColumn() != 1 {
+		t.Errorf(`expected <br> at 4:1 but found %d:%d`, toks[3].GetLine(), toks[3].GetColumn())
+	}
+}
+
+func TestCommentsDoctypeAndCData(t *testing.T) {
+	input := `<!DOCTYPE html><html><!-- a > b <div> --><body><![CDATA[ x < y ]]><?xml version="1.0"?><!----><!--></body></html>`
+	toks, err := TokenizeHtml([]rune(input))
+	if err != nil {
+		panic(err)
+	}
+	expected := []HtmlTokenType{Doctype, HtmlOpen, Comment, HtmlOpen, CData, Comment, Comment, Comment, HtmlClose, HtmlClose}
+	if len(toks) != len(expected) {
+		t.Fatalf(`expected %d toks but found %d`, len(expected), len(toks))
+	}
+	for i, typ := range expected {
+		if toks[i].GetType() != typ {
+			t.Errorf(`expected token %d (%s) to be %s but it was %s`, i, toks[i].GetLexeme(), typ, toks[i].GetType())
+		}
+	}
+	if toks[2].GetLexeme() != "<!-- a > b <div> -->" {
+		t.Errorf(`expected the comment to keep its markup but got %s`, toks[2].GetLexeme())
+	}
+	// the whole page should come back out unchanged
+	if Construct(toks) != input {
+		t.Errorf(`expected %s to round-trip but got %s`, input, Construct(toks))
+	}
+	// an unclosed comment is an error rather than a tag
+	if _, err := TokenizeHtml([]rune("<p><!-- never closed</p>")); err == nil {
+		t.Errorf(`expected an unclosed comment to fail`)
+	}
+}
+
+func TestRawTextElements(t *testing.T) {
+	input := `<div><script>if (a < b && c > d) { el.innerHTML = "<p>hi</p>" } // </scripts</script><style>ul > li { color: red }</style><textarea><b>not bold</b></textarea><title>A < B</title><script></script></div>`
+	toks, err := TokenizeHtml([]rune(input))
+	if err != nil {
+		panic(err)
+	}
+	expected := []struct {
+		typ    HtmlTokenType
+		lexeme string
+	}{
+		{HtmlOpen, "<div>"},
+		{HtmlOpen, "<script>"},
+		{RawText, `if (a < b && c > d) { el.innerHTML = "<p>hi</p>" } // </scripts`},
+		{HtmlClose, "</script>"},
+		{HtmlOpen, "<style>"},
+		{RawText, "ul > li { color: red }"},
+		{HtmlClose, "</style>"},
+		{HtmlOpen, "<textarea>"},
+		{RawText, "<b>not bold</b>"},
+		{HtmlClose, "</textarea>"},
+		{HtmlOpen, "<title>"},
+		{RawText, "A < B"},
+		{HtmlClose, "</title>"},
+		{HtmlOpen, "<script>"},
+		{HtmlClose, "</script>"},
+		{HtmlClose, "</div>"},
+	}
+	if len(toks) != len(expected) {
+		t.Fatalf(`expected %d toks but found %d: %v`, len(expected), len(toks), toks)
+	}
+	for i, want := range expected {
+		if toks[i].GetType() != want.typ || toks[i].GetLexeme() != want.lexeme {
+			t.Errorf(`expected token %d to be %s %q but it was %s %q`, i, want.typ, want.lexeme, toks[i].GetType(), toks[i].GetLexeme())
+		}
+	}
+}
+
+func TestVoidElements(t *testing.T) {
+	// a forgotten </div> should not turn the div into a void element
+	input := []rune("<section>\n  <div>\n    <br><img src='a.png'><my-icon />\n</section>")
+	toks, err := TokenizeHtml(input, Whitespace(WhitespaceDrop))
+	if err != nil {
+		panic(err)
+	}
+	expected := []HtmlTokenType{HtmlOpen, HtmlOpen, HtmlVoid, HtmlVoid, HtmlVoid, HtmlClose}
+	if len(toks) != len(expected) {
+		t.Fatalf(`expected %d toks but found %d`, len(expected), len(toks))
+	}
+	for i, typ := range expected {
+		if toks[i].GetType() != typ {
+			t.Errorf(`expected %s to be %s but it was %s`, toks[i].GetLexeme(), typ, toks[i].GetType())
+		}
+	}
+	// strict mode reports the unclosed div along with where it starts
+	_, err = TokenizeHtml(input, Strict())
+	if err == nil {
+		t.Fatalf(`expected strict mode to reject the unclosed <div>`)
+	}
+	if !strings.Contains(err.Error(), "2:3") || !strings.Contains(err.Error(), "<div>") {
+		t.Errorf(`expected the error to point at the <div> on 2:3 but got: %s`, err)
+	}
+	// but a well formed document is fine in strict mode
+	if _, err := TokenizeHtml([]rune("<p>hi<br></p>"), Strict()); err != nil {
+		t.Errorf(`expected strict mode to accept a well formed document but got: %s`, err)
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	tests := []struct {
+		lexeme string
+		name   string
+		raw    string
+	}{
+		{`<div>`, "div", "div"},
+		{`<DIV class="x">`, "div", "DIV"},
+		{"<div\n  class=\"x\">", "div", "div"},
+		{"<Div\tid=a/>", "div", "Div"},
+		{`</Div >`, "div", "Div"},
+		{`<br/>`, "br", "br"},
+		{`<a href="/x/y">`, "a", "a"},
+		{`<clippath>`, "clipPath", "clippath"},
+		{`<LinearGradient id="g">`, "linearGradient", "LinearGradient"},
+		{`<x-Icon />`, "x-icon", "x-Icon"},
+	}
+	for _, tt := range tests {
+		tok := HtmlToken{Lexeme: tt.lexeme, Type: HtmlOpen}
+		if strings.HasPrefix(tt.lexeme, "</") {
+			tok.Type = HtmlClose
+		}
+		if name := GetTagName(tok); name != tt.name {
+			t.Errorf(`expected %q to be named %s but got %q`, tt.lexeme, tt.name, name)
+		}
+		if raw := RawTagName(tok); raw != tt.raw {
+			t.Errorf(`expected %q to be written %s but got %q`, tt.lexeme, tt.raw, raw)
+		}
+	}
+	// tags pair up whatever their case or however they are split
+	toks, err := TokenizeHtml([]rune("<DIV\n  class=\"x\"><P>a</p><SCRIPT>if (a<b) {}</Script><Title>t</TITLE></div>"), Strict())
+	if err != nil {
+		t.Fatalf(`expected mixed-case tags to pair up but got: %s`, err)
+	}
+	m := NewMatchTable(toks)
+	if m.Closes(0) != len(toks)-1 {
+		t.Errorf(`expected <DIV> to be closed by </div>`)
+	}
+	if toks[5].GetType() != RawText || toks[5].GetLexeme() != "if (a<b) {}" {
+		t.Errorf(`expected the script to hold raw text but got %s %q`, toks[5].GetType(), toks[5].GetLexeme())
+	}
+	if !IsVoid(HtmlToken{Lexeme: "<BR>", Type: HtmlOpen}) {
+		t.Errorf(`expected <BR> to be void`)
+	}
+}
@@ -0,0 +1,158 @@
+package token
+
+import "fmt"
+
+// MatchTable pairs up tags in a token slice. For every HtmlOpen token it
+// holds the index of its HtmlClose token, for every HtmlClose the index of
+// its HtmlOpen, and -1 for everything else, including tags that have no
+// partner because of mismatched nesting or a stray close tag.
+type MatchTable []int
+
+// NewMatchTable builds the table for toks in a single pass. Open tags are
+// kept on a stack; a close tag pairs with the nearest open tag of the same
+// name, and any open tags above that one are left unclosed. A close tag
+// with no open tag of its name on the stack is stray.
+func NewMatchTable(toks []Token) MatchTable {
+	m, _ := matchTags(toks)
+	return m
+}
+
+// matchTags builds the MatchTable and also records, for every open tag
+// left unpaired, the index of the close tag that ended it implicitly, or
+// -1 if it was still open at the end.
+func matchTags(toks []Token) (MatchTable, []int) {
+	m := make(MatchTable, len(toks))
+	closedBy := make([]int, len(toks))
+	stack := []int{}
+	open := map[string]int{} // open tags on the stack, by name
+	for i, tok := range toks {
+		m[i] = -1
+		closedBy[i] = -1
+		switch tok.GetType() {
+		case HtmlOpen:
+			stack = append(stack, i)
+			open[GetTagName(tok)]++
+		case HtmlClose:
+			name := GetTagName(tok)
+			if open[name] == 0 {
+				continue
+			}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				topName := GetTagName(toks[top])
+				open[topName]--
+				if topName == name {
+					m[top] = i
+					m[i] = top
+					break
+				}
+				closedBy[top] = i
+			}
+		}
+	}
+	return m, closedBy
+}
+
+// CheckNesting reports every stray close tag, every element closed early
+// by an ancestor's end tag, and every element that is never closed.
+func CheckNesting(toks []Token) Diagnostics {
+	m, closedBy := matchTags(toks)
+	diags := Diagnostics{}
+	for i, tok := range toks {
+		switch {
+		case tok.GetType() == HtmlClose && m[i] == -1:
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeStrayCloseTag,
+				Message:  fmt.Sprintf("%s has no matching open tag", tok.GetLexeme()),
+				Span:     tok.GetSpan(),
+			})
+		case tok.GetType() == HtmlOpen && m[i] == -1 && closedBy[i] != -1:
+			closer := toks[closedBy[i]]
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeMismatchedTag,
+				Message:  fmt.Sprintf("<%s> is closed by %s at %s before its own end tag", RawTagName(tok), closer.GetLexeme(), closer.GetSpan().Start),
+				Span:     tok.GetSpan(),
+			})
+		case tok.GetType() == HtmlOpen && m[i] == -1:
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeUnclosedElement,
+				Message:  fmt.Sprintf("<%s> is not a void element and is never closed", RawTagName(tok)),
+				Span:     tok.GetSpan(),
+			})
+		}
+	}
+	return diags
+}
+
+// Closes returns the index of the token that closes the open tag at i, or
+// -1 if it is never closed.
+func (m MatchTable) Closes(i int) int {
+	if i < 0 || i >= len(m) || m[i] < i {
+		return -1
+	}
+	return m[i]
+}
+
+// ClosingTag finds the HtmlClose token that matches the HtmlOpen token at
+// index i of toks, the slice the table was built from. Returns nil if no
+// matching closing tag is found.
+func (m MatchTable) ClosingTag(i int, toks []Token) (Token, int, error) {
+	if i < 0 || i >= len(toks) || toks[i].GetType() == HtmlVoid {
+		return nil, -1, nil
+	}
+	if toks[i].GetType() != HtmlOpen {
+		return toks[i], -1, fmt.Errorf(`attempted to extract the closing tag from an invalid token: %s`, toks[i].GetLexeme())
+	}
+	closeI := m.Closes(i)
+	if closeI == -1 {
+		// failed to find a matching closing tag
+		return nil, -1, nil
+	}
+	return toks[closeI], closeI, nil
+}
+
+// FullElement returns the source of the element starting at index i of
+// toks, the slice the table was built from, or an empty string if the
+// element is never closed.
+func (m MatchTable) FullElement(i int, toks []Token) (string, error) {
+	if i < 0 || i >= len(toks) {
+		return "", nil
+	}
+	switch toks[i].GetType() {
+	case HtmlVoid:
+		return toks[i].GetLexeme(), nil
+	case HtmlOpen:
+	default:
+		return "", fmt.Errorf(`attempted to extract an element from an invalid token: %s`, toks[i].GetLexeme())
+	}
+	endI := m.Closes(i)
+	if endI == -1 {
+		return "", nil
+	}
+	return Construct(toks[i : endI+1]), nil
+}
+
+// ShedOuterHtml returns the tokens inside the element that spans all of
+// toks, the slice the table was built from, or toks unchanged if they are
+// not a single element.
+func (m MatchTable) ShedOuterHtml(toks []Token) ([]Token, error) {
+	if len(toks) == 0 {
+		return toks, nil
+	}
+	firstTok := toks[0]
+	if firstTok.GetType() == HtmlClose {
+		return []Token{}, fmt.Errorf("you cannot shed the outerhtml of an html closing tag: %s", firstTok.GetType())
+	}
+	if firstTok.GetType() != HtmlOpen {
+		// text, comments and the like have no outer html to shed
+		return toks, nil
+	}
+	if m.Closes(0) == len(toks)-1 {
+		return toks[1 : len(toks)-1], nil
+	}
+	return toks, nil
+}
@@ -0,0 +1,83 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMatchTable(t *testing.T) {
+	// the <b> is never closed, </i> is stray and the <p> closes normally
+	toks, err := TokenizeHtml([]rune(`<div><p><b>bold</p></i><br></div>`))
+	if err != nil {
+		panic(err)
+	}
+	m := NewMatchTable(toks)
+	expected := []int{
+		7,  // <div>
+		4,  // <p>
+		-1, // <b>
+		-1, // bold
+		1,  // </p>
+		-1, // </i>
+		-1, // <br>
+		0,  // </div>
+	}
+	if len(m) != len(expected) {
+		t.Fatalf(`expected a table of %d entries but got %d`, len(expected), len(m))
+	}
+	for i, want := range expected {
+		if m[i] != want {
+			t.Errorf(`expected %s to pair with %d but it paired with %d`, toks[i].GetLexeme(), want, m[i])
+		}
+	}
+	if m.Closes(0) != 7 || m.Closes(7) != -1 || m.Closes(2) != -1 {
+		t.Errorf(`Closes should only look forward from open tags`)
+	}
+}
+
+func TestMatchTableLookups(t *testing.T) {
+	toks, err := TokenizeHtml([]rune(`<ul><li>a<br></li><li><b>b</b></li></ul><p>`))
+	if err != nil {
+		panic(err)
+	}
+	// one table answers every lookup the one-off functions do
+	m := NewMatchTable(toks)
+	for i, tok := range toks {
+		got, gotErr := m.FullElement(i, toks)
+		want, wantErr := ExtractFullElement(tok, i, toks)
+		if got != want || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf(`expected FullElement(%d) to give %q but got %q`, i, want, got)
+		}
+		closer, closeI, _ := m.ClosingTag(i, toks)
+		wantCloser, wantI, _ := GetClosingTag(tok, i, toks)
+		if closeI != wantI || (closer == nil) != (wantCloser == nil) {
+			t.Errorf(`expected ClosingTag(%d) to give %d but got %d`, i, wantI, closeI)
+		}
+	}
+	if elm, _ := m.FullElement(5, toks); elm != "<li><b>b</b></li>" {
+		t.Errorf(`expected the second list item but got %q`, elm)
+	}
+	if elm, _ := m.FullElement(len(toks)-1, toks); elm != "" {
+		t.Errorf(`expected an unclosed element to give nothing but got %q`, elm)
+	}
+	ul := toks[:len(toks)-1]
+	if inner, _ := NewMatchTable(ul).ShedOuterHtml(ul); len(inner) != len(ul)-2 {
+		t.Errorf(`expected shedding the list to drop its own tags`)
+	}
+}
+
+func BenchmarkMatchTableDeepNesting(b *testing.B) {
+	for _, depth := range []int{1000, 4000, 16000} {
+		toks, err := TokenizeHtml([]rune(strings.Repeat("<div>", depth) + strings.Repeat("</div>", depth)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewMatchTable(toks)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*depth), "ns/level")
+		})
+	}
+}
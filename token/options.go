@@ -0,0 +1,36 @@
+package token
+
+// Options control how TokenizeHtml reads its input.
+type Options struct {
+	// Strict reports a non-void element without a closing tag as an error
+	// instead of leaving it open.
+	Strict bool
+	// Whitespace says what to do with whitespace in text. It defaults to
+	// WhitespacePreserve, so that Construct(toks) rebuilds the source.
+	Whitespace WhitespaceMode
+}
+
+// Option changes a single setting in Options.
+type Option func(*Options)
+
+// Strict turns on strict mode.
+func Strict() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+// Whitespace sets how whitespace in text is handled.
+func Whitespace(mode WhitespaceMode) Option {
+	return func(o *Options) {
+		o.Whitespace = mode
+	}
+}
+
+func newOptions(opts []Option) Options {
+	o := Options{Whitespace: WhitespacePreserve}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
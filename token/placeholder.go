@@ -0,0 +1,285 @@
+package token
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PlaceholderToken is a "%s name%" interpolation found in text or in an
+// attribute value. Verb is its printf verb and Expr is the expression,
+// which may be a dotted path such as "friend.Name".
+type PlaceholderToken struct {
+	Lexeme string
+	Verb   Verb
+	Expr   string
+	Span   Span
+}
+
+// Verb is the printf verb of a placeholder, such as %s, %5d or %.2f.
+// Width and Precision are -1 when they are not given.
+type Verb struct {
+	Char      rune
+	Flags     string
+	Width     int
+	Precision int
+}
+
+// String returns the verb as fmt writes it, e.g. "%.2f".
+func (v Verb) String() string {
+	var b strings.Builder
+	b.WriteByte('%')
+	b.WriteString(v.Flags)
+	if v.Width >= 0 {
+		b.WriteString(strconv.Itoa(v.Width))
+	}
+	if v.Precision >= 0 {
+		b.WriteByte('.')
+		b.WriteString(strconv.Itoa(v.Precision))
+	}
+	b.WriteRune(v.Char)
+	return b.String()
+}
+
+// Sprint formats val exactly as fmt.Sprintf does with this verb.
+func (v Verb) Sprint(val any) string {
+	return fmt.Sprintf(v.String(), val)
+}
+
+// GoType returns the Go type a prop formatted with this verb should have.
+func (v Verb) GoType() string {
+	return placeholderVerbs[v.Char]
+}
+
+func (tok PlaceholderToken) GetLexeme() string {
+	return tok.Lexeme
+}
+
+func (tok PlaceholderToken) GetType() HtmlTokenType {
+	return Placeholder
+}
+
+func (tok PlaceholderToken) GetLine() int {
+	return tok.Span.Start.Line
+}
+
+func (tok PlaceholderToken) GetColumn() int {
+	return tok.Span.Start.Column
+}
+
+func (tok PlaceholderToken) GetSpan() Span {
+	return tok.Span
+}
+
+// Path returns the parts of the expression, e.g. ["friend", "Name"].
+func (tok PlaceholderToken) Path() []string {
+	return strings.Split(tok.Expr, ".")
+}
+
+// Codes for problems with placeholders.
+const (
+	CodeUnterminatedPlaceholder = "unterminated-placeholder"
+	CodeMalformedPlaceholder    = "malformed-placeholder"
+)
+
+// placeholderVerbs are the format verbs a placeholder may use, along with
+// the Go type each one implies for the value it formats.
+var placeholderVerbs = map[rune]string{
+	's': "string",
+	'q': "string",
+	'd': "int",
+	'x': "int",
+	'f': "float64",
+	't': "bool",
+	'v': "any",
+}
+
+// UnescapePercent turns each "%%" in literal text into a single '%'.
+// Tokens keep the escape as written; parser.TextContent applies this.
+func UnescapePercent(s string) string {
+	return strings.ReplaceAll(s, "%%", "%")
+}
+
+// splitPlaceholders breaks the text of tok into literal tokens of its own
+// type and PlaceholderTokens. A '%' that does not start a well formed
+// placeholder stays part of the literal text and is reported.
+func splitPlaceholders(tok Token) ([]Token, Diagnostics) {
+	runes := []rune(tok.GetLexeme())
+	pos := positions(runes, tok.GetSpan().Start)
+	out := []Token{}
+	diags := Diagnostics{}
+	literal := 0
+	for i := 0; i < len(runes); {
+		if runes[i] != '%' {
+			i++
+			continue
+		}
+		p := parsePlaceholder(runes, i)
+		if p.escaped {
+			i += p.n
+			continue
+		}
+		if p.problem != nil {
+			p.problem.Span = Span{Start: pos[i], End: pos[i+p.n]}
+			diags = append(diags, *p.problem)
+			i += p.n
+			continue
+		}
+		if literal < i {
+			out = append(out, literalToken(runes[literal:i], tok.GetType(), Span{Start: pos[literal], End: pos[i]}))
+		}
+		out = append(out, PlaceholderToken{
+			Lexeme: string(runes[i : i+p.n]),
+			Verb:   p.verb,
+			Expr:   p.expr,
+			Span:   Span{Start: pos[i], End: pos[i+p.n]},
+		})
+		i += p.n
+		literal = i
+	}
+	if literal == 0 {
+		return []Token{tok}, diags
+	}
+	if literal < len(runes) {
+		out = append(out, literalToken(runes[literal:], tok.GetType(), Span{Start: pos[literal], End: pos[len(runes)]}))
+	}
+	return out, diags
+}
+
+// literalToken builds a piece of text left over between placeholders. It
+// keeps the type of the token it was split from: a space between two
+// placeholders is part of the text, not a whitespace-only run to drop.
+func literalToken(runes []rune, typ HtmlTokenType, span Span) Token {
+	return HtmlToken{
+		Lexeme: string(runes),
+		Type:   typ,
+		Span:   span,
+	}
+}
+
+// placeholder is the outcome of reading a placeholder starting at a '%'.
+// n is how many runes were read, whether or not they formed a placeholder.
+type placeholder struct {
+	n       int
+	verb    Verb
+	expr    string
+	escaped bool // "%%", a literal percent
+	problem *Diagnostic
+}
+
+// parsePlaceholder reads "%verb expr%" starting at runes[i], where the
+// verb may carry flags, a width and a precision as in fmt. Placeholders
+// never cross a line or a tag. "%%" is a literal percent.
+func parsePlaceholder(runes []rune, i int) placeholder {
+	if i+1 < len(runes) && runes[i+1] == '%' {
+		return placeholder{n: 2, escaped: true}
+	}
+	verb, j := parseVerb(runes, i+1)
+	if j >= len(runes) || !unicode.IsLetter(runes[j]) || j+1 >= len(runes) || (runes[j+1] != ' ' && runes[j+1] != '\t') {
+		return placeholder{n: 1, problem: &Diagnostic{
+			Severity: SeverityWarning,
+			Code:     CodeMalformedPlaceholder,
+			Message:  `'%' does not start a placeholder like "%s name%"; write %% for a literal percent`,
+		}}
+	}
+	verb.Char = runes[j]
+	k := j + 1
+	for k < len(runes) && runes[k] != '%' && runes[k] != '\n' {
+		k++
+	}
+	if k == len(runes) || runes[k] == '\n' {
+		return placeholder{n: k - i, problem: &Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeUnterminatedPlaceholder,
+			Message:  fmt.Sprintf("placeholder %s is never closed with '%%'", strings.TrimSpace(string(runes[i:k]))),
+		}}
+	}
+	n := k + 1 - i
+	expr := strings.TrimSpace(string(runes[j+1 : k]))
+	if _, ok := placeholderVerbs[verb.Char]; !ok {
+		return placeholder{n: n, problem: &Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeMalformedPlaceholder,
+			Message:  fmt.Sprintf("%s is not a supported placeholder verb", verb),
+		}}
+	}
+	if !isExprPath(expr) {
+		return placeholder{n: n, problem: &Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeMalformedPlaceholder,
+			Message:  fmt.Sprintf("%q is not a valid placeholder expression", expr),
+		}}
+	}
+	return placeholder{n: n, verb: verb, expr: expr}
+}
+
+// parseVerb reads the flags, width and precision of a verb starting at
+// runes[j] and returns the index of the rune that should be the verb.
+func parseVerb(runes []rune, j int) (Verb, int) {
+	v := Verb{Width: -1, Precision: -1}
+	for j < len(runes) && strings.ContainsRune("+-#0", runes[j]) {
+		v.Flags += string(runes[j])
+		j++
+	}
+	v.Width, j = parseDigits(runes, j)
+	if j < len(runes) && runes[j] == '.' {
+		v.Precision, j = parseDigits(runes, j+1)
+		if v.Precision == -1 {
+			v.Precision = 0
+		}
+	}
+	return v, j
+}
+
+// parseDigits reads a run of digits starting at runes[j], returning -1 if
+// there are none.
+func parseDigits(runes []rune, j int) (int, int) {
+	start := j
+	for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+		j++
+	}
+	if j == start {
+		return -1, j
+	}
+	n, err := strconv.Atoi(string(runes[start:j]))
+	if err != nil {
+		return -1, j
+	}
+	return n, j
+}
+
+// isExprPath reports whether expr is an identifier or a dotted path of them.
+func isExprPath(expr string) bool {
+	for _, part := range strings.Split(expr, ".") {
+		if part == "" {
+			return false
+		}
+		for i, r := range part {
+			if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// positions returns the position of every rune in runes followed by the
+// position just past the last one, given where the first one sits.
+func positions(runes []rune, start Position) []Position {
+	out := make([]Position, len(runes)+1)
+	p := start
+	for i, r := range runes {
+		out[i] = p
+		p.Offset += len(string(r))
+		p.Rune++
+		if r == '\n' {
+			p.Line++
+			p.Column = 1
+		} else {
+			p.Column++
+		}
+	}
+	out[len(runes)] = p
+	return out
+}
@@ -0,0 +1,155 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	src, err := os.ReadFile("../tests/components/greeting.t.html")
+	if err != nil {
+		panic(err)
+	}
+	toks, err := TokenizeHtml([]rune(string(src)))
+	if err != nil {
+		t.Fatalf(`expected greeting.t.html to tokenize cleanly but got: %s`, err)
+	}
+	found := []PlaceholderToken{}
+	for _, tok := range toks {
+		if ph, ok := tok.(PlaceholderToken); ok {
+			found = append(found, ph)
+		}
+	}
+	expected := []string{"name", "age", "friend.Name"}
+	if len(found) != len(expected) {
+		t.Fatalf(`expected %d placeholders but found %d`, len(expected), len(found))
+	}
+	for i, expr := range expected {
+		if found[i].Verb.String() != "%s" || found[i].Expr != expr {
+			t.Errorf(`expected %%s %s%% but found %s %s%%`, expr, found[i].Verb, found[i].Expr)
+		}
+		if string(src[found[i].Span.Start.Offset:found[i].Span.End.Offset]) != found[i].Lexeme {
+			t.Errorf(`expected the span of %s to cover it in the source`, found[i].Lexeme)
+		}
+	}
+	if path := found[2].Path(); len(path) != 2 || path[0] != "friend" || path[1] != "Name" {
+		t.Errorf(`expected friend.Name to split into a path but got %v`, path)
+	}
+	// text around a placeholder stays as literal text
+	toks, _ = TokenizeHtml([]rune("<h1>Hello, %s name%!</h1>"))
+	types := []HtmlTokenType{HtmlOpen, Text, Placeholder, Text, HtmlClose}
+	for i, typ := range types {
+		if toks[i].GetType() != typ {
+			t.Errorf(`expected token %d to be %s but it was %s`, i, typ, toks[i].GetType())
+		}
+	}
+	// a space between placeholders is text, not a whitespace-only run
+	toks, _ = TokenizeHtml([]rune("<p>%s first% %s last%</p>"), Whitespace(WhitespaceDrop))
+	if len(toks) != 5 || toks[2].GetType() != Text || toks[2].GetLexeme() != " " {
+		t.Errorf(`expected the space between placeholders to stay as Text`)
+	}
+	// placeholders inside attribute values are picked up on the tag
+	toks, _ = TokenizeHtml([]rune(`<a href="/users/%s user.ID%" title='x'>`))
+	tag := toks[0].(HtmlToken)
+	if len(tag.Placeholders) != 1 || tag.Placeholders[0].(PlaceholderToken).Expr != "user.ID" {
+		t.Errorf(`expected the href placeholder on the tag but got %v`, tag.Placeholders)
+	}
+	if tag.Placeholders[0].GetColumn() != 17 {
+		t.Errorf(`expected the href placeholder at column 17 but it is at %d`, tag.Placeholders[0].GetColumn())
+	}
+}
+
+func TestPlaceholderDiagnostics(t *testing.T) {
+	toks, err := TokenizeHtml([]rune("<p>%s name</p><p>%s friend..Name%</p><p>50% off</p>"))
+	var diags Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf(`expected Diagnostics but got %v`, err)
+	}
+	expected := []struct {
+		code     string
+		severity Severity
+	}{
+		{CodeUnterminatedPlaceholder, SeverityError},
+		{CodeMalformedPlaceholder, SeverityError},
+		{CodeMalformedPlaceholder, SeverityWarning},
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics but got %d:\n%s", len(expected), len(diags), diags)
+	}
+	for i, want := range expected {
+		if diags[i].Code != want.code || diags[i].Severity != want.severity {
+			t.Errorf(`expected diagnostic %d to be a %s %s but got: %s`, i, want.code, want.severity, diags[i])
+		}
+	}
+	// malformed placeholders are left as plain text
+	for _, tok := range toks {
+		if tok.GetType() == Placeholder {
+			t.Errorf(`expected no placeholders but found %s`, tok.GetLexeme())
+		}
+	}
+}
+
+func TestPlaceholderVerbs(t *testing.T) {
+	tests := []struct {
+		src    string
+		verb   string
+		goType string
+		val    any
+	}{
+		{"%s name%", "%s", "string", "Ada"},
+		{"%d count%", "%d", "int", 42},
+		{"%5d count%", "%5d", "int", 42},
+		{"%-5d count%", "%-5d", "int", 42},
+		{"%f price%", "%f", "float64", 3.14159},
+		{"%.2f price%", "%.2f", "float64", 3.14159},
+		{"%+8.3f price%", "%+8.3f", "float64", 3.14159},
+		{"%v item%", "%v", "any", []int{1, 2}},
+		{"%t ok%", "%t", "bool", true},
+		{"%q name%", "%q", "string", `say "hi"`},
+		{"%x id%", "%x", "int", 255},
+		{"%#x id%", "%#x", "int", 255},
+	}
+	for _, tt := range tests {
+		toks, err := TokenizeHtml([]rune("<p>" + tt.src + "</p>"))
+		if err != nil {
+			t.Errorf(`expected %s to tokenize cleanly but got: %s`, tt.src, err)
+			continue
+		}
+		ph, ok := toks[1].(PlaceholderToken)
+		if !ok {
+			t.Errorf(`expected %s to be a placeholder but it was %s`, tt.src, toks[1].GetType())
+			continue
+		}
+		if ph.Verb.String() != tt.verb {
+			t.Errorf(`expected the verb of %s to be %s but it was %s`, tt.src, tt.verb, ph.Verb)
+		}
+		if ph.Verb.GoType() != tt.goType {
+			t.Errorf(`expected %s to imply %s but it implied %s`, tt.src, tt.goType, ph.Verb.GoType())
+		}
+		if got, want := ph.Verb.Sprint(tt.val), fmt.Sprintf(tt.verb, tt.val); got != want {
+			t.Errorf(`expected %s to format %v as %q but got %q`, tt.src, tt.val, want, got)
+		}
+	}
+	// %% is a literal percent and never a placeholder
+	toks, err := TokenizeHtml([]rune("<p>50%% off, %d n%%% left</p>"))
+	if err != nil {
+		t.Fatalf(`expected %%%% to tokenize cleanly but got: %s`, err)
+	}
+	types := []HtmlTokenType{HtmlOpen, Text, Placeholder, Text, HtmlClose}
+	for i, typ := range types {
+		if toks[i].GetType() != typ {
+			t.Errorf(`expected token %d to be %s but it was %s`, i, typ, toks[i].GetType())
+		}
+	}
+	if text := UnescapePercent(toks[1].GetLexeme()); text != "50% off, " {
+		t.Errorf(`expected %%%% to unescape to a percent but got %q`, text)
+	}
+	// unsupported verbs are errors
+	_, err = TokenizeHtml([]rune("<p>%z name%</p>"))
+	var diags Diagnostics
+	if !errors.As(err, &diags) || len(diags) != 1 || diags[0].Code != CodeMalformedPlaceholder {
+		t.Errorf(`expected %%z to be a malformed placeholder but got %v`, err)
+	}
+}
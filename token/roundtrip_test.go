@@ -0,0 +1,92 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// roundTrips reports whether tokenizing src, both in memory and streamed,
+// gives back tokens that rebuild src exactly with spans that tile it.
+func roundTrips(t *testing.T, src string) bool {
+	toks, _ := TokenizeHtml([]rune(src))
+	if got := Construct(toks); got != src {
+		t.Logf("TokenizeHtml(%q) rebuilt %q", src, got)
+		return false
+	}
+	offset := 0
+	for _, tok := range toks {
+		span := tok.GetSpan()
+		if span.Start.Offset != offset || src[span.Start.Offset:span.End.Offset] != tok.GetLexeme() {
+			t.Logf("TokenizeHtml(%q) gave %s a span of %s", src, tok.GetLexeme(), span)
+			return false
+		}
+		offset = span.End.Offset
+	}
+	tz := NewTokenizer(strings.NewReader(src))
+	streamed := []Token{}
+	for tok, err := range tz.All() {
+		if err != nil {
+			t.Logf("streaming %q failed: %s", src, err)
+			return false
+		}
+		streamed = append(streamed, tok)
+	}
+	if got := Construct(streamed); got != src {
+		t.Logf("streaming %q rebuilt %q", src, got)
+		return false
+	}
+	return true
+}
+
+func TestRoundTripComponents(t *testing.T) {
+	paths, err := filepath.Glob("../tests/components/*.html")
+	if err != nil {
+		panic(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf(`expected to find components to round-trip`)
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		if !roundTrips(t, string(src)) {
+			t.Errorf(`%s does not round-trip`, path)
+		}
+	}
+}
+
+// fragments are glued together at random to build awkward inputs, many of
+// them malformed on purpose.
+var fragments = []string{
+	"<div class='a b'>", "</div>", "<p>", "</p>", "<br>", "<img src=\"x.png\"/>",
+	"<input type='text' disabled>", "text", "héllo wörld", "%s name%", "50% off",
+	" ", "  ", "\n", "\t", "\r\n", "<", ">", "'", "\"", "</", "/>",
+	"<!--", "-->", "<!-- a > b -->", "<![CDATA[", "]]>", "<!DOCTYPE html>", "<?xml?>",
+	"<script>", "</script>", "if (a < b) {}", "<style>", "</style>", "<pre>", "</pre>",
+	"<textarea>", "</textarea>", "<title>", "</title>", "<ul _for='x in xs'>", "::?",
+}
+
+func TestRoundTripGenerated(t *testing.T) {
+	glued := func(picks []uint16) bool {
+		var b strings.Builder
+		for _, p := range picks {
+			b.WriteString(fragments[int(p)%len(fragments)])
+		}
+		return roundTrips(t, b.String())
+	}
+	if err := quick.Check(glued, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+	// and entirely random strings too
+	random := func(src string) bool {
+		return roundTrips(t, src)
+	}
+	if err := quick.Check(random, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"fmt"
+
+	"github.com/phillip-england/gtml/lexer"
+)
+
+// Position is a single location in the source.
+type Position struct {
+	Offset int // Byte offset.
+	Rune   int // Rune offset.
+	Line   int // 1-based line.
+	Column int // 1-based column, counted in runes.
+}
+
+// Span is the half-open source range [Start, End) covered by a token or
+// node. End is the position just past the last rune.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
+// Len returns the number of runes covered by the span.
+func (s Span) Len() int {
+	return s.End.Rune - s.Start.Rune
+}
+
+// Contains reports whether the byte offset falls inside the span.
+func (s Span) Contains(offset int) bool {
+	return offset >= s.Start.Offset && offset < s.End.Offset
+}
+
+// SpanOf returns the span running from the first token to the last.
+func SpanOf(toks []Token) Span {
+	if len(toks) == 0 {
+		return Span{}
+	}
+	return Span{
+		Start: toks[0].GetSpan().Start,
+		End:   toks[len(toks)-1].GetSpan().End,
+	}
+}
+
+func positionOf(c lexer.Cursor) Position {
+	return Position{
+		Offset: c.Offset,
+		Rune:   c.Pos,
+		Line:   c.Line,
+		Column: c.Column,
+	}
+}
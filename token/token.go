@@ -1,12 +1,17 @@
 package token
 
-import "github.com/phillip-england/gtml/logi"
+import (
+	"strings"
+
+	"github.com/phillip-england/gtml/logi"
+)
 
 type Token interface {
 	GetLexeme() string
 	GetType() HtmlTokenType
 	GetLine() int
 	GetColumn() int
+	GetSpan() Span
 }
 
 func LogTokens(toks []Token) {
@@ -16,11 +21,13 @@ func LogTokens(toks []Token) {
 	}
 }
 
+// Construct joins the lexemes of toks. Tokens from TokenizeHtml with the
+// default options are lossless, so this rebuilds the original source.
 func Construct(toks[]Token) string {
-	out := ""
+	var out strings.Builder
 	for _, tok := range toks {
-		out += tok.GetLexeme()
+		out.WriteString(tok.GetLexeme())
 	}
-	return out
+	return out.String()
 }
 
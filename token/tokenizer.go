@@ -0,0 +1,540 @@
+package token
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/phillip-england/gtml/lexer"
+	"github.com/phillip-england/gtml/stur"
+)
+
+const (
+	// readChunk is how many runes the Tokenizer pulls from its reader at a time.
+	readChunk = 4096
+	// maxTextRun caps how many runes a single token may hold when streaming
+	// so that nothing can grow the buffer without limit. Longer runs of
+	// text, raw text, comments, doctypes and CDATA sections are emitted as
+	// several consecutive tokens of the same type, and a '<' with no '>'
+	// within that many runes is read as text.
+	maxTextRun = 64 * 1024
+)
+
+// Tokenizer reads tokens one at a time from an io.Reader. At most
+// maxTextRun runes plus one chunk are held in memory, so arbitrarily
+// large inputs can be processed with bounded memory.
+type Tokenizer struct {
+	r    *bufio.Reader
+	buf  []rune   // Runes read but not yet tokenized.
+	base Position // Position of buf[0] in the whole input.
+	eof  bool     // Whether the reader has been drained into buf.
+
+	diags   Diagnostics
+	ws      whitespaceFilter
+	pending []Token // Tokens already scanned but not yet returned.
+
+	// rawTag is the name of the raw text element (such as script) whose
+	// content is being read, or "" outside of one.
+	rawTag string
+	// ns follows whether tags are inside SVG or MathML.
+	ns namespaceStack
+
+	// scanned is how much of the buffer has been searched for the end of
+	// the token at its start, so that a fill resumes the search rather than
+	// starting over. quote is the quote still open at that point in a tag.
+	scanned int
+	quote   rune
+	// decl is the sequence that ends the comment, doctype or CDATA section
+	// of type declType being emitted in pieces, or "" outside of one.
+	// declStart is where it began.
+	decl      string
+	declType  HtmlTokenType
+	declStart Position
+	// noTagEnd is set once the rest of the input is known to hold no '>'.
+	noTagEnd bool
+}
+
+// NewTokenizer creates a Tokenizer reading from r. Strict mode needs the
+// whole document and is ignored when streaming.
+func NewTokenizer(r io.Reader, opts ...Option) *Tokenizer {
+	o := newOptions(opts)
+	return &Tokenizer{
+		r:    bufio.NewReader(r),
+		base: Position{Line: 1, Column: 1},
+		ws:   whitespaceFilter{mode: o.Whitespace},
+	}
+}
+
+// newRuneTokenizer creates a Tokenizer over input that is already in memory.
+func newRuneTokenizer(input []rune, o Options) *Tokenizer {
+	return &Tokenizer{
+		buf:  input,
+		base: Position{Line: 1, Column: 1},
+		eof:  true,
+		ws:   whitespaceFilter{mode: o.Whitespace},
+	}
+}
+
+// Next returns the next token, or io.EOF once the input is exhausted.
+func (t *Tokenizer) Next() (Token, error) {
+	tok, err := t.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.GetType() == HtmlOpen && IsVoid(tok) {
+		return asVoid(tok), nil
+	}
+	return tok, nil
+}
+
+// All returns an iterator over the remaining tokens for use in a range
+// loop. Iteration stops after the first error is yielded.
+func (t *Tokenizer) All() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for {
+			tok, err := t.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(tok, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// next returns the next raw token without classifying void elements.
+func (t *Tokenizer) next() (Token, error) {
+	for {
+		if len(t.pending) > 0 {
+			tok := t.pending[0]
+			t.pending = t.pending[1:]
+			if tok = t.ws.apply(tok); tok != nil {
+				return tok, nil
+			}
+			continue
+		}
+		tok, n, err := t.scan()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			if t.eof {
+				return nil, io.EOF
+			}
+			if err := t.fill(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		t.pending = t.placeholders(tok)
+	}
+}
+
+// placeholders splits text into literal and Placeholder tokens, and finds
+// the placeholders in a tag's attribute values. Script and style content
+// is left alone.
+func (t *Tokenizer) placeholders(tok Token) []Token {
+	switch tok.GetType() {
+	case Text, EmptySpace:
+	case RawText:
+		if t.rawTag != "title" && t.rawTag != "textarea" {
+			return []Token{tok}
+		}
+	case HtmlOpen:
+		h := tok.(HtmlToken)
+		attrs, diags := ParseAttributes(h.Lexeme, h.Span.Start)
+		if len(attrs) > 0 {
+			h.Attrs = attrs
+		}
+		for _, attr := range attrs {
+			for _, part := range attr.Parts {
+				if part.GetType() == Placeholder {
+					h.Placeholders = append(h.Placeholders, part)
+				}
+			}
+		}
+		t.diags = append(t.diags, diags...)
+		return []Token{h}
+	default:
+		return []Token{tok}
+	}
+	toks, diags := splitPlaceholders(tok)
+	t.diags = append(t.diags, diags...)
+	return toks
+}
+
+// scan reads a single token from the front of the buffer and reports how
+// many runes it consumed; n == 0 means more input is needed to finish the
+// token.
+func (t *Tokenizer) scan() (Token, int, error) {
+	l := lexer.NewLexer(t.buf)
+	if l.Terminated {
+		return nil, 0, nil
+	}
+	if t.decl != "" {
+		return t.markup(l, t.declType, t.decl, 0)
+	}
+	var tok Token
+	unterminated := false
+	if t.rawTag != "" {
+		n, ok := t.rawTextEnd(l)
+		if !ok {
+			return nil, 0, nil
+		}
+		if n > 0 {
+			tok = HtmlToken{
+				Lexeme: string(l.CollectFromMark()),
+				Type:   RawText,
+				Span:   t.span(l),
+			}
+			t.advance(l)
+			return tok, n, nil
+		}
+		// the end tag is up next, so go back to reading markup
+		t.rawTag = ""
+	}
+	if l.CharIs("<") && len(l.Source) < len(cdataOpen) && !t.eof {
+		// not enough input to tell what kind of markup this is
+		return nil, 0, nil
+	}
+	if l.CharIs("<") && isDeclaration(l) {
+		typ, closer := declaration(l)
+		return t.markup(l, typ, closer, max(l.Pos+2-len(closer), 0))
+	}
+	if l.CharIs("<") && t.noTagEnd {
+		unterminated = true
+	} else if l.CharIs("<") {
+		if end := t.tagEnd(); end != -1 {
+			for l.Pos < end {
+				l.Step()
+			}
+			return t.tag(l), t.advance(l), nil
+		}
+		if !t.eof && len(t.buf) < maxTextRun {
+			return nil, 0, nil
+		}
+		if end := indexRune(l.Source, '>'); end != -1 {
+			// every '>' is quoted, so the tag ends at the first one and the
+			// quote still open before it is the one never closed
+			q := unclosedQuote(l.Source[:end])
+			for l.Pos < end {
+				l.Step()
+			}
+			tok = t.tag(l)
+			t.report(CodeUnterminatedQuote, t.spanBetween(l, q, q+1), "%c in %s is never closed", l.Source[q], tok.GetLexeme())
+			return tok, t.advance(l), nil
+		}
+		// without a '>' there is no tag, so the '<' is read as text, and
+		// so is every later '<' once the whole input has been read
+		t.noTagEnd = t.eof
+		t.scanned = 0
+		unterminated = true
+	}
+	// text runs up to the next '<' or the end of the input
+	end := indexFrom(t.buf, "<", max(t.scanned, 1))
+	if end == -1 {
+		if !t.eof && len(t.buf) < maxTextRun {
+			t.scanned = len(t.buf)
+			return nil, 0, nil
+		}
+		end = len(t.buf)
+	}
+	for l.Pos < end-1 {
+		l.Step()
+	}
+	buf := string(l.CollectFromMark())
+	typ := Text
+	if len(stur.Squeeze(buf)) == 0 {
+		typ = EmptySpace
+	}
+	tok = HtmlToken{
+		Lexeme: buf,
+		Type:   typ,
+		Span:   t.span(l),
+	}
+	if unterminated {
+		t.report(CodeUnterminatedTag, t.span(l), "tag is never closed with '>'")
+	}
+	return tok, t.advance(l), nil
+}
+
+// markup reads a comment, doctype or CDATA section of type typ up to the
+// closer that ends it, which starts no earlier than from. One too long to
+// hold is handed back in pieces.
+func (t *Tokenizer) markup(l *lexer.Lexer, typ HtmlTokenType, closer string, from int) (Token, int, error) {
+	if t.decl == "" {
+		t.declStart = t.base
+	}
+	end := indexFrom(t.buf, closer, max(t.scanned, from))
+	unterminated := false
+	switch {
+	case end != -1:
+		end += len(closer) - 1
+		t.decl = ""
+	case !t.eof && len(t.buf) < maxTextRun:
+		// look again at the runes that could start the closer after a fill
+		t.scanned = max(len(t.buf)-len(closer)+1, from)
+		return nil, 0, nil
+	case !t.eof:
+		// keep back enough runes that the closer cannot be split between
+		// two pieces and the next piece is never empty
+		end = len(t.buf) - len(closer) - 1
+		t.decl, t.declType = closer, typ
+	default:
+		// the rest of the input belongs to the declaration
+		end = len(t.buf) - 1
+		t.decl = ""
+		unterminated = true
+	}
+	for l.Pos < end {
+		l.Step()
+	}
+	tok := HtmlToken{
+		Lexeme: string(l.CollectFromMark()),
+		Type:   typ,
+		Span:   t.span(l),
+	}
+	if unterminated {
+		t.report(CodeUnterminatedComment, Span{Start: t.declStart, End: tok.Span.End}, "%s is never closed with %q", typ, closer)
+	}
+	return tok, t.advance(l), nil
+}
+
+// tagEnd returns the index of the '>' that ends the tag at the start of
+// the buffer, skipping any inside quotes as lexer.WalkUntilSkipQuotes
+// does, or -1. It carries on from where the last call left off.
+func (t *Tokenizer) tagEnd() int {
+	for ; t.scanned < len(t.buf); t.scanned++ {
+		switch r := t.buf[t.scanned]; {
+		case t.quote == 0 && r == '>':
+			return t.scanned
+		case t.quote == 0 && (r == '\'' || r == '"'):
+			t.quote = r
+		case r == t.quote:
+			t.quote = 0
+		}
+	}
+	return -1
+}
+
+// tag builds an HtmlOpen or HtmlClose token from the runes between the
+// start of the buffer and the lexer's current '>'.
+func (t *Tokenizer) tag(l *lexer.Lexer) Token {
+	buf := string(l.CollectFromMark())
+	sq := stur.Squeeze(buf)
+	typ := HtmlOpen
+	if len(sq) > 2 && sq[1] == '/' {
+		typ = HtmlClose
+	}
+	tok := HtmlToken{
+		Lexeme: buf,
+		Type:   typ,
+		Span:   t.span(l),
+	}
+	tok.Namespace = t.ns.visit(tok)
+	if name := strings.ToLower(GetTagName(tok)); typ == HtmlOpen && tok.Namespace == NamespaceHTML && rawTextElements[name] && !IsSelfClosing(tok) {
+		t.rawTag = name
+	}
+	return tok
+}
+
+// unclosedQuote returns the index of a quote in src that is still open at
+// the end, following the same rules as lexer.WalkUntilSkipQuotes, or -1.
+func unclosedQuote(src []rune) int {
+	open := -1
+	for i, r := range src {
+		if r != '\'' && r != '"' {
+			continue
+		}
+		if open == -1 {
+			open = i
+		} else if src[open] == r {
+			open = -1
+		}
+	}
+	return open
+}
+
+// indexFrom returns the index of the first seq in src starting at or
+// after from, or -1.
+func indexFrom(src []rune, seq string, from int) int {
+	target := []rune(seq)
+	for i := from; i+len(target) <= len(src); i++ {
+		if slices.Equal(src[i:i+len(target)], target) {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexRune(src []rune, target rune) int {
+	for i, r := range src {
+		if r == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// report records a problem found while scanning.
+func (t *Tokenizer) report(code string, span Span, format string, args ...any) {
+	t.diags = append(t.diags, Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	})
+}
+
+// Diagnostics returns the problems found in the input read so far. The
+// Tokenizer recovers from each of them and keeps going.
+func (t *Tokenizer) Diagnostics() Diagnostics {
+	return t.diags
+}
+
+// advance drops everything up to and including the lexer's current rune
+// from the buffer and returns how many runes that was.
+func (t *Tokenizer) advance(l *lexer.Lexer) int {
+	n := l.Pos + 1
+	t.base = t.shift(positionOf(l.CursorAfter()))
+	t.buf = t.buf[n:]
+	t.scanned, t.quote = 0, 0
+	return n
+}
+
+// rawTextElements hold text that is never parsed as markup; their content
+// runs until the matching end tag.
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+	"title":    true,
+}
+
+// IsRawTextElement reports whether the content of the element called
+// name is raw text rather than markup.
+func IsRawTextElement(name string) bool {
+	return rawTextElements[strings.ToLower(name)]
+}
+
+// rawTextEnd walks over the content of the current raw text element and
+// returns how many runes of it are in the buffer, leaving the lexer on the
+// last of them. ok is false when more input is needed to find the end.
+func (t *Tokenizer) rawTextEnd(l *lexer.Lexer) (n int, ok bool) {
+	closer := "</" + t.rawTag
+	for {
+		if l.Pos+len(closer) >= len(l.Source) && !t.eof {
+			if len(l.Source) < maxTextRun || l.Pos == 0 {
+				return 0, false
+			}
+			// the run is too long to hold, so hand back what we have
+			l.StepBack()
+			return l.Pos + 1, true
+		}
+		if l.HasPrefixFold(closer) && isRawTextEnd(l.Peek(len(closer))) {
+			n := l.Pos
+			l.StepBack()
+			return n, true
+		}
+		if l.Pos+1 >= len(l.Source) {
+			// the element is never closed, so the rest of the input is its content
+			return len(l.Source), true
+		}
+		l.Step()
+	}
+}
+
+// isRawTextEnd reports whether r can follow the name in a raw text
+// element's end tag.
+func isRawTextEnd(r rune) bool {
+	return r == 0 || r == '>' || r == '/' || unicode.IsSpace(r)
+}
+
+const (
+	commentOpen = "<!--"
+	cdataOpen   = "<![CDATA["
+	doctypeOpen = "<!DOCTYPE"
+)
+
+// isDeclaration reports whether the lexer sits on markup that is not a
+// tag: a comment, doctype, CDATA section or processing instruction.
+func isDeclaration(l *lexer.Lexer) bool {
+	return l.HasPrefix("<!") || l.HasPrefix("<?")
+}
+
+// declaration classifies the markup the lexer sits on and returns the
+// sequence that ends it. Anything other than a comment, CDATA section or
+// doctype is a bogus comment which ends at the first '>', as in the spec.
+func declaration(l *lexer.Lexer) (HtmlTokenType, string) {
+	switch {
+	case l.HasPrefix(commentOpen):
+		// stop short of the opener's last '-' so "<!-->" closes as an empty comment
+		for range len(commentOpen) - 2 {
+			l.Step()
+		}
+		return Comment, "-->"
+	case l.HasPrefix(cdataOpen):
+		return CData, "]]>"
+	case l.HasPrefixFold(doctypeOpen):
+		return Doctype, ">"
+	default:
+		return Comment, ">"
+	}
+}
+
+// span returns the absolute span from the start of the buffer through the
+// lexer's current rune.
+func (t *Tokenizer) span(l *lexer.Lexer) Span {
+	return Span{
+		Start: t.base,
+		End:   t.shift(positionOf(l.CursorAfter())),
+	}
+}
+
+// spanBetween returns the absolute span of the buffer runes [from, to).
+func (t *Tokenizer) spanBetween(l *lexer.Lexer, from, to int) Span {
+	return Span{
+		Start: t.shift(positionOf(l.CursorAt(from))),
+		End:   t.shift(positionOf(l.CursorAt(to))),
+	}
+}
+
+// shift turns a position relative to the start of the buffer into a
+// position in the whole input.
+func (t *Tokenizer) shift(p Position) Position {
+	out := Position{
+		Offset: t.base.Offset + p.Offset,
+		Rune:   t.base.Rune + p.Rune,
+		Line:   t.base.Line + p.Line - 1,
+		Column: p.Column,
+	}
+	if p.Line == 1 {
+		out.Column = t.base.Column + p.Column - 1
+	}
+	return out
+}
+
+// fill reads the next chunk of runes from the reader into the buffer.
+// Already tokenized runes are not carried over.
+func (t *Tokenizer) fill() error {
+	buf := make([]rune, len(t.buf), len(t.buf)+readChunk)
+	copy(buf, t.buf)
+	defer func() { t.buf = buf }()
+	for len(buf) < cap(buf) {
+		r, _, err := t.r.ReadRune()
+		if err == io.EOF {
+			t.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buf = append(buf, r)
+	}
+	return nil
+}
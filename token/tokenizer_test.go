@@ -0,0 +1,188 @@
+package token
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTokenizerMatchesTokenizeHtml(t *testing.T) {
+	input := `
+		<form>
+			<h1>Login Form – ünïcode</h1>
+			<input type='text' name='username'>
+			<p>a > b</p>
+			<script>if (a < b) { go("</p>") }</script>
+			<!-- <p>commented out</p> -->
+		</form>
+	`
+	want, err := TokenizeHtml([]rune(input))
+	if err != nil {
+		panic(err)
+	}
+	// reading a single byte at a time forces tokens to straddle refills
+	tz := NewTokenizer(iotest.OneByteReader(strings.NewReader(input)))
+	got := []Token{}
+	for tok, err := range tz.All() {
+		if err != nil {
+			t.Fatalf(`unexpected error while streaming: %s`, err)
+		}
+		got = append(got, tok)
+	}
+	if len(got) != len(want) {
+		t.Fatalf(`expected %d streamed toks but found %d`, len(want), len(got))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf(`token %d differs: streamed %+v but TokenizeHtml gave %+v`, i, got[i], want[i])
+		}
+	}
+}
+
+func TestTokenizerVoidElements(t *testing.T) {
+	tz := NewTokenizer(strings.NewReader(`<div><br><img src='a.png'/><x-icon /></div>`))
+	expected := []HtmlTokenType{HtmlOpen, HtmlVoid, HtmlVoid, HtmlVoid, HtmlClose}
+	for i, typ := range expected {
+		tok, err := tz.Next()
+		if err != nil {
+			t.Fatalf(`unexpected error: %s`, err)
+		}
+		if tok.GetType() != typ {
+			t.Errorf(`expected token %d to be %s but it was %s`, i, typ, tok.GetType())
+		}
+	}
+	if _, err := tz.Next(); err != io.EOF {
+		t.Errorf(`expected io.EOF after the last token but got %v`, err)
+	}
+}
+
+// repeatReader produces the same chunk of text n times without ever
+// holding the whole input in memory.
+type repeatReader struct {
+	chunk string
+	n     int
+	r     *strings.Reader
+}
+
+func (rr *repeatReader) Read(p []byte) (int, error) {
+	for rr.r == nil || rr.r.Len() == 0 {
+		if rr.n == 0 {
+			return 0, io.EOF
+		}
+		rr.n--
+		rr.r = strings.NewReader(rr.chunk)
+	}
+	return rr.r.Read(p)
+}
+
+func TestTokenizerBoundedMemory(t *testing.T) {
+	// roughly 10MB of markup, plus one very long run of text
+	rr := &repeatReader{chunk: "<li class='item'>some text on a line</li>\n", n: 240000}
+	long := strings.NewReader("<p>" + strings.Repeat("x", 1<<20) + "</p>")
+	tz := NewTokenizer(io.MultiReader(rr, long))
+	count := 0
+	limit := maxTextRun + readChunk
+	for {
+		_, err := tz.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf(`unexpected error: %s`, err)
+		}
+		if cap(tz.buf) > limit {
+			t.Fatalf(`buffer grew to %d runes, expected at most %d`, cap(tz.buf), limit)
+		}
+		count++
+	}
+	if count < 720000 {
+		t.Errorf(`expected at least 720000 tokens but found %d`, count)
+	}
+	// comments and stray '<'s are held to the same limit and come out in
+	// pieces, but nothing is lost
+	tests := []struct {
+		src  string
+		typ  HtmlTokenType
+		code string
+	}{
+		{"<!--" + strings.Repeat("-x", 1<<19) + "-->", Comment, ""},
+		{"<![CDATA[" + strings.Repeat("]x", 1<<19) + "]]>", CData, ""},
+		{"<!--" + strings.Repeat("x", 1<<20), Comment, CodeUnterminatedComment},
+		{"<" + strings.Repeat("x", 1<<20), Text, CodeUnterminatedTag},
+		{"<p title='" + strings.Repeat("x", 1<<20), Text, CodeUnterminatedTag},
+	}
+	for _, tt := range tests {
+		tz := NewTokenizer(io.MultiReader(strings.NewReader(tt.src), strings.NewReader("<p>")))
+		var b strings.Builder
+		for tok, err := range tz.All() {
+			if err != nil {
+				t.Fatalf(`unexpected error: %s`, err)
+			}
+			if cap(tz.buf) > limit {
+				t.Fatalf(`buffer grew to %d runes reading %.12s, expected at most %d`, cap(tz.buf), tt.src, limit)
+			}
+			if tok.GetType() != HtmlOpen && tok.GetType() != tt.typ {
+				t.Errorf(`expected %.12s to come out as %s but found %s`, tt.src, tt.typ, tok.GetType())
+			}
+			b.WriteString(tok.GetLexeme())
+		}
+		if b.String() != tt.src+"<p>" {
+			t.Errorf(`expected the pieces of %.12s to add up to the input`, tt.src)
+		}
+		diags := tz.Diagnostics()
+		if tt.code == "" && len(diags) > 0 || tt.code != "" && (len(diags) != 1 || diags[0].Code != tt.code) {
+			t.Errorf(`expected %.12s to report %q but got %v`, tt.src, tt.code, diags)
+		}
+	}
+}
+
+func TestForeignContent(t *testing.T) {
+	toks, err := TokenizeHtml([]rune(`<svg viewBox="0 0 1 1"><path d="M0 0"/><g><image href="a.png"></image></g><style>a > b</style>`+
+		`<foreignObject><img src="b.png"><p>x</p></foreignObject></svg><img src="c.png"><math><mi>y</mi><mspace/></math>`), Strict())
+	if err != nil {
+		t.Fatalf(`expected the svg to tokenize cleanly but got: %s`, err)
+	}
+	expected := []struct {
+		lexeme string
+		typ    HtmlTokenType
+		ns     Namespace
+	}{
+		{`<svg viewBox="0 0 1 1">`, HtmlOpen, NamespaceSVG},
+		{`<path d="M0 0"/>`, HtmlVoid, NamespaceSVG},
+		{`<g>`, HtmlOpen, NamespaceSVG},
+		{`<image href="a.png">`, HtmlOpen, NamespaceSVG},
+		{`</image>`, HtmlClose, NamespaceSVG},
+		{`</g>`, HtmlClose, NamespaceSVG},
+		{`<style>`, HtmlOpen, NamespaceSVG},
+		{`a > b`, Text, NamespaceHTML},
+		{`</style>`, HtmlClose, NamespaceSVG},
+		{`<foreignObject>`, HtmlOpen, NamespaceSVG},
+		{`<img src="b.png">`, HtmlVoid, NamespaceHTML},
+		{`<p>`, HtmlOpen, NamespaceHTML},
+		{`x`, Text, NamespaceHTML},
+		{`</p>`, HtmlClose, NamespaceHTML},
+		{`</foreignObject>`, HtmlClose, NamespaceSVG},
+		{`</svg>`, HtmlClose, NamespaceSVG},
+		{`<img src="c.png">`, HtmlVoid, NamespaceHTML},
+		{`<math>`, HtmlOpen, NamespaceMathML},
+		{`<mi>`, HtmlOpen, NamespaceMathML},
+		{`y`, Text, NamespaceHTML},
+		{`</mi>`, HtmlClose, NamespaceMathML},
+		{`<mspace/>`, HtmlVoid, NamespaceMathML},
+		{`</math>`, HtmlClose, NamespaceMathML},
+	}
+	if len(toks) != len(expected) {
+		t.Fatalf(`expected %d tokens but got %d`, len(expected), len(toks))
+	}
+	for i, want := range expected {
+		tok := toks[i].(HtmlToken)
+		if tok.Lexeme != want.lexeme || tok.Type != want.typ || tok.Namespace != want.ns {
+			t.Errorf(`expected %s %q in %q but got %s %q in %q`, want.typ, want.lexeme, want.ns, tok.Type, tok.Lexeme, tok.Namespace)
+		}
+	}
+	if attr, _ := GetAttribute(toks[0].(HtmlToken), "viewbox"); attr.Name != "viewBox" {
+		t.Errorf(`expected viewBox to keep its case but got %s`, attr.Name)
+	}
+}
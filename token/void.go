@@ -0,0 +1,47 @@
+package token
+
+import "strings"
+
+// voidElements are the elements the HTML spec defines as never having
+// content or an end tag.
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// IsVoidElement reports whether name is an HTML void element.
+func IsVoidElement(name string) bool {
+	return voidElements[strings.ToLower(name)]
+}
+
+// IsVoid reports whether a tag token stands alone, either because it
+// names a void element or because it is written as "<tag />". In SVG and
+// MathML only the second applies.
+func IsVoid(tok Token) bool {
+	if h, ok := tok.(HtmlToken); ok && h.Namespace != NamespaceHTML {
+		return IsSelfClosing(tok)
+	}
+	return IsVoidElement(GetTagName(tok)) || IsSelfClosing(tok)
+}
+
+// IsSelfClosing reports whether a tag token ends in "/>".
+func IsSelfClosing(tok Token) bool {
+	if tok.GetType() != HtmlOpen && tok.GetType() != HtmlVoid {
+		return false
+	}
+	s := strings.TrimSpace(tok.GetLexeme())
+	s = strings.TrimSpace(strings.TrimSuffix(s, ">"))
+	return strings.HasSuffix(s, "/")
+}
@@ -0,0 +1,102 @@
+package token
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WhitespaceMode says what happens to whitespace between and inside text.
+type WhitespaceMode int
+
+const (
+	// WhitespaceDrop removes whitespace-only runs and leaves text as written.
+	WhitespaceDrop WhitespaceMode = iota
+	// WhitespaceCollapse turns every run of whitespace into a single space.
+	WhitespaceCollapse
+	// WhitespacePreserve keeps all whitespace exactly as written.
+	WhitespacePreserve
+)
+
+// preservedElements always keep their whitespace, whatever the mode.
+// textarea is also raw text, so its content is never touched either way.
+var preservedElements = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+}
+
+// IsPreservedElement reports whether the element called name keeps its
+// whitespace whatever the mode.
+func IsPreservedElement(name string) bool {
+	return preservedElements[strings.ToLower(name)]
+}
+
+// whitespaceFilter applies a WhitespaceMode to a stream of tokens, keeping
+// track of whether it is inside an element that preserves whitespace.
+type whitespaceFilter struct {
+	mode      WhitespaceMode
+	preserved int // depth of open elements that preserve whitespace
+}
+
+// apply returns tok with the mode applied, or nil if it should be dropped.
+func (f *whitespaceFilter) apply(tok Token) Token {
+	switch tok.GetType() {
+	case HtmlOpen:
+		// "<pre/>" stands alone, so no end tag will follow to lower the depth
+		if preservedElements[strings.ToLower(GetTagName(tok))] && !IsSelfClosing(tok) {
+			f.preserved++
+		}
+	case HtmlClose:
+		if preservedElements[strings.ToLower(GetTagName(tok))] && f.preserved > 0 {
+			f.preserved--
+		}
+	case EmptySpace, Text:
+		if f.preserved > 0 || f.mode == WhitespacePreserve {
+			return tok
+		}
+		if tok.GetType() == EmptySpace && f.mode == WhitespaceDrop {
+			return nil
+		}
+		if f.mode == WhitespaceCollapse {
+			return HtmlToken{
+				Lexeme: collapseWhitespace(tok.GetLexeme()),
+				Type:   tok.GetType(),
+				Span:   tok.GetSpan(),
+			}
+		}
+	}
+	return tok
+}
+
+// ApplyWhitespace returns toks with the mode applied. The content of pre
+// and textarea elements is left alone.
+func ApplyWhitespace(toks []Token, mode WhitespaceMode) []Token {
+	f := whitespaceFilter{mode: mode}
+	out := make([]Token, 0, len(toks))
+	for _, tok := range toks {
+		if tok = f.apply(tok); tok != nil {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// collapseWhitespace replaces each run of whitespace in s with one space.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package token
+
+import "testing"
+
+func TestWhitespaceModes(t *testing.T) {
+	input := []rune("<p><b>a</b> <i>b</i>\n  Hello,\n\t  World</p><pre>\n  keep   this\n</pre>")
+	lexemes := func(mode WhitespaceMode) []string {
+		toks, err := TokenizeHtml(input, Whitespace(mode))
+		if err != nil {
+			panic(err)
+		}
+		out := []string{}
+		for _, tok := range toks {
+			if tok.GetType() == Text || tok.GetType() == EmptySpace {
+				out = append(out, tok.GetLexeme())
+			}
+		}
+		return out
+	}
+	cases := []struct {
+		mode     WhitespaceMode
+		expected []string
+	}{
+		{WhitespaceDrop, []string{"a", "b", "\n  Hello,\n\t  World", "\n  keep   this\n"}},
+		{WhitespaceCollapse, []string{"a", " ", "b", " Hello, World", "\n  keep   this\n"}},
+		{WhitespacePreserve, []string{"a", " ", "b", "\n  Hello,\n\t  World", "\n  keep   this\n"}},
+	}
+	for _, c := range cases {
+		got := lexemes(c.mode)
+		if len(got) != len(c.expected) {
+			t.Errorf(`mode %d: expected %q but got %q`, c.mode, c.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.expected[i] {
+				t.Errorf(`mode %d: expected text %d to be %q but got %q`, c.mode, i, c.expected[i], got[i])
+			}
+		}
+	}
+	// a self-closing pre holds nothing, so the text after it is collapsed
+	toks, err := TokenizeHtml([]rune("<pre/><p> a    b </p><textarea/><p>c  d</p>"), Whitespace(WhitespaceCollapse))
+	if err != nil {
+		panic(err)
+	}
+	if toks[2].GetLexeme() != " a b " || toks[6].GetLexeme() != "c d" {
+		t.Errorf(`expected text after <pre/> and <textarea/> to be collapsed but got %q and %q`, toks[2].GetLexeme(), toks[6].GetLexeme())
+	}
+	// the default is to keep everything
+	toks, _ = TokenizeHtml(input)
+	if Construct(toks) != string(input) {
+		t.Errorf(`expected the default mode to be WhitespacePreserve`)
+	}
+}